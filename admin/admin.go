@@ -0,0 +1,275 @@
+// Package admin serves debugging endpoints on a separate, opt-in port:
+// pprof, expvar, runtime log level, build info and the registered grpc
+// services.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	micro_http "github.com/duolacloud/micro/http"
+	"github.com/duolacloud/micro/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// ErrInsecure is returned when binding a non loopback address without a token
+var ErrInsecure = errors.New("admin: non loopback address requires a token")
+
+// ServiceInfoProvider lists grpc services, implemented by *grpc.Server and
+// GrpcServer
+type ServiceInfoProvider interface {
+	GetServiceInfo() map[string]grpc.ServiceInfo
+}
+
+// Option set admin server option
+type Option func(*options)
+
+type options struct {
+	host        string
+	port        int
+	token       string
+	level       *zap.AtomicLevel
+	levels      *logging.Levels
+	services    ServiceInfoProvider
+	httpOptions []micro_http.Option
+}
+
+func defaultOptions() *options {
+	return &options{
+		host: "127.0.0.1",
+		port: 6060,
+	}
+}
+
+// WithHost set the host to bind, default is 127.0.0.1
+func WithHost(host string) Option {
+	return func(o *options) {
+		o.host = host
+	}
+}
+
+// WithPort set the port to bind, default is 6060
+func WithPort(port int) Option {
+	return func(o *options) {
+		o.port = port
+	}
+}
+
+// WithToken require "Authorization: Bearer <token>" on every request
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithLogLevel serve level at /loglevel, GET returns it and PUT
+// {"level":"debug"} changes it
+func WithLogLevel(level zap.AtomicLevel) Option {
+	return func(o *options) {
+		o.level = &level
+	}
+}
+
+// WithLogLevels serve the per logger levels at /loglevels
+func WithLogLevels(levels *logging.Levels) Option {
+	return func(o *options) {
+		o.levels = levels
+	}
+}
+
+// WithGrpcServices list the services of provider at /grpc/services
+func WithGrpcServices(provider ServiceInfoProvider) Option {
+	return func(o *options) {
+		o.services = provider
+	}
+}
+
+// WithHTTPServerOptions set options of the underlying http server
+func WithHTTPServerOptions(opts ...micro_http.Option) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, opts...)
+	}
+}
+
+// Server is the admin http server
+type Server struct {
+	*micro_http.Server
+	handler http.Handler
+}
+
+// NewServer returns an admin server, it refuses to bind a non loopback
+// address unless a token is set
+func NewServer(opts ...Option) (*Server, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.token == "" && !isLoopback(o.host) {
+		return nil, ErrInsecure
+	}
+
+	handler := newHandler(o)
+	httpOptions := append([]micro_http.Option{
+		micro_http.WithHost(o.host),
+		micro_http.WithPort(o.port),
+		micro_http.WithHandler(handler),
+		// profiles and traces stream for longer than the default timeout
+		micro_http.WithWriteTimeout(0),
+	}, o.httpOptions...)
+
+	return &Server{
+		Server:  micro_http.NewServer(httpOptions...),
+		handler: handler,
+	}, nil
+}
+
+// Handler returns the admin handler, to mount it on another server
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+func newHandler(o *options) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/buildinfo", serveBuildInfo)
+	if o.level != nil {
+		mux.Handle("/loglevel", o.level)
+	}
+	if o.levels != nil {
+		mux.Handle("/loglevels", o.levels)
+	}
+	if o.services != nil {
+		mux.Handle("/grpc/services", servicesHandler(o.services))
+	}
+	return protect(o.token, mux)
+}
+
+// protect checks the bearer token, without token only loopback clients are
+// allowed
+func protect(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			host, _, _ := net.SplitHostPort(r.RemoteAddr)
+			if !isLoopback(host) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type buildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Main      module            `json:"main"`
+	Deps      []module          `json:"deps"`
+	Settings  map[string]string `json:"settings"`
+}
+
+type module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	Replace string `json:"replace,omitempty"`
+}
+
+func newModule(m *debug.Module) module {
+	out := module{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		out.Replace = m.Replace.Path + "@" + m.Replace.Version
+	}
+	return out
+}
+
+func serveBuildInfo(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		http.Error(w, "build info not available", http.StatusNotFound)
+		return
+	}
+
+	out := buildInfo{
+		GoVersion: info.GoVersion,
+		Path:      info.Path,
+		Main:      newModule(&info.Main),
+		Settings:  make(map[string]string, len(info.Settings)),
+	}
+	for _, dep := range info.Deps {
+		out.Deps = append(out.Deps, newModule(dep))
+	}
+	for _, s := range info.Settings {
+		out.Settings[s.Key] = s.Value
+	}
+	writeJSON(w, out)
+}
+
+type service struct {
+	Name     string      `json:"name"`
+	Methods  []method    `json:"methods"`
+	Metadata interface{} `json:"metadata,omitempty"`
+}
+
+type method struct {
+	Name            string `json:"name"`
+	ClientStreaming bool   `json:"client_streaming"`
+	ServerStreaming bool   `json:"server_streaming"`
+}
+
+func servicesHandler(provider ServiceInfoProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		infos := provider.GetServiceInfo()
+		services := make([]service, 0, len(infos))
+		for name, info := range infos {
+			s := service{Name: name, Metadata: info.Metadata}
+			for _, m := range info.Methods {
+				s.Methods = append(s.Methods, method{
+					Name:            m.Name,
+					ClientStreaming: m.IsClientStream,
+					ServerStreaming: m.IsServerStream,
+				})
+			}
+			services = append(services, s)
+		}
+		sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+		writeJSON(w, services)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestInsecureBind(t *testing.T) {
+	if _, err := NewServer(WithHost("0.0.0.0")); err != ErrInsecure {
+		t.Fatalf("expected ErrInsecure, got %v", err)
+	}
+	if _, err := NewServer(WithHost("0.0.0.0"), WithToken("secret")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+
+	s, err := NewServer(WithToken("secret"), WithLogLevel(level), WithGrpcServices(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	do := func(method, path, body string) *http.Response {
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp, _ := http.Get(ts.URL + "/buildinfo")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	// the token alone, without the Bearer scheme, is rejected
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/buildinfo", nil)
+	req.Header.Set("Authorization", "secret")
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the Bearer scheme, got %d", resp.StatusCode)
+	}
+
+	resp = do(http.MethodPut, "/loglevel", `{"level":"debug"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || level.Level() != zapcore.DebugLevel {
+		t.Fatalf("level not changed: %d %s", resp.StatusCode, level.Level())
+	}
+
+	resp = do(http.MethodGet, "/grpc/services", "")
+	var services []service
+	json.NewDecoder(resp.Body).Decode(&services)
+	resp.Body.Close()
+	if len(services) != 1 || services[0].Name != "grpc.health.v1.Health" || len(services[0].Methods) == 0 {
+		t.Fatalf("unexpected services %+v", services)
+	}
+
+	resp = do(http.MethodGet, "/debug/vars", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expvar: %d", resp.StatusCode)
+	}
+}
@@ -17,7 +17,6 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/oklog/run"
 )
@@ -33,8 +32,16 @@ func (h *HelloHandler) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.
 }
 
 func main() {
-	l, _ := zap.NewDevelopment()
-	logger := logging.NewLogger(l)
+	logger, closeLogger, err := logging.New(logging.Config{
+		Level:    "debug",
+		Encoding: "console",
+		Service:  "hello",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer closeLogger()
 
 	// Setup metrics.
 	metrics := grpcprom.NewServerMetrics(
@@ -44,13 +51,13 @@ func main() {
 	)
 
 	registerFn := func(s *grpc.Server) {
-		reflection.Register(s)
 		pb.RegisterHelloServiceServer(s, &HelloHandler{})
 	}
 
 	svr := server.NewGrpcServer(
 		registerFn,
 		server.WithPort(50052),
+		server.WithReflection(),
 		server.WithStatsHandler(otelgrpc.NewServerHandler()),
 		server.WithServerMetrics(metrics),
 		server.WithUnaryInterceptor(
@@ -89,6 +96,7 @@ func main() {
 	// g.Add(run.SignalHandler(context.Background(), syscall.SIGINT, syscall.SIGTERM))
 	if err := g.Run(); err != nil {
 		logger.Error("program interrupted", zap.Error(err))
+		closeLogger()
 		os.Exit(1)
 	}
 }
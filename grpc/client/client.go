@@ -4,6 +4,7 @@ package client
 import (
 	"context"
 
+	"github.com/duolacloud/micro/logging"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -23,6 +24,7 @@ type options struct {
 	statsHandler       stats.Handler
 	unaryInterceptors  []grpc.UnaryClientInterceptor
 	streamInterceptors []grpc.StreamClientInterceptor
+	installLogger      func()
 }
 
 func defaultOptions() *options {
@@ -82,11 +84,27 @@ func WithStreamInterceptor(interceptors ...grpc.StreamClientInterceptor) Option
 	}
 }
 
+// WithInternalLogger route grpc internal logging through logger. grpclog is
+// global, so this affects every server and client in the process and only
+// the first server or client created with this option installs its logger
+func WithInternalLogger(logger logging.Logger, opts ...logging.AdapterOption) Option {
+	return func(o *options) {
+		o.installLogger = func() {
+			logging.InstallGrpcLoggerOnce(logger, opts...)
+		}
+	}
+}
+
 // Dial to grpc server
 func Dial(ctx context.Context, endpoint string, opts ...Option) (*grpc.ClientConn, error) {
 	o := defaultOptions()
 	o.apply(opts...)
 
+	// grpclog must be replaced before the client is created
+	if o.installLogger != nil {
+		o.installLogger()
+	}
+
 	var dialOptions []grpc.DialOption
 
 	// service discovery
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/admin"
+	channelz "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/reflection"
+)
+
+// WithReflection register the server reflection service
+func WithReflection() Option {
+	return func(o *options) {
+		o.reflection = true
+	}
+}
+
+// WithChannelz register the channelz service
+func WithChannelz() Option {
+	return func(o *options) {
+		o.channelz = true
+	}
+}
+
+// WithAdmin register reflection and the grpc admin services, channelz and
+// CSDS when an xds package is linked in
+func WithAdmin() Option {
+	return func(o *options) {
+		o.reflection = true
+		o.admin = true
+	}
+}
+
+// WithAdminAllowList only register the reflection, channelz and admin
+// services when env is one of allowed, e.g. WithAdminAllowList(os.Getenv("ENV"), "dev", "staging")
+func WithAdminAllowList(env string, allowed ...string) Option {
+	return func(o *options) {
+		o.adminEnv = env
+		o.adminAllowList = allowed
+	}
+}
+
+// adminAllowed reports whether debug services may be registered
+func (o *options) adminAllowed() bool {
+	if o.adminAllowList == nil {
+		return true
+	}
+	for _, env := range o.adminAllowList {
+		if env == o.adminEnv {
+			return true
+		}
+	}
+	return false
+}
+
+// registerAdmin registers the enabled debug services, the returned cleanup
+// releases the admin services
+func registerAdmin(srv *grpc.Server, o *options) func() {
+	if !o.reflection && !o.channelz && !o.admin {
+		return nil
+	}
+	if !o.adminAllowed() {
+		log.Printf("grpc debug services disabled in environment %q", o.adminEnv)
+		return nil
+	}
+
+	if o.reflection {
+		reflection.Register(srv)
+	}
+	if o.admin {
+		cleanup, err := admin.Register(srv)
+		if err != nil {
+			log.Printf("failed to register grpc admin services: %v", err)
+			return nil
+		}
+		return cleanup
+	}
+	if o.channelz {
+		channelz.RegisterChannelzServiceToServer(srv)
+	}
+	return nil
+}
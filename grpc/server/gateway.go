@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/vanguard/vanguardgrpc"
+	micro_http "github.com/duolacloud/micro/http"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// GatewayRegisterFn registers grpc-gateway handlers on mux, e.g. the generated
+// RegisterHelloServiceHandler. conn reaches the grpc server in-process, so
+// gateway calls go through the same interceptors as native grpc calls.
+type GatewayRegisterFn func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// ErrSecureSharedPort is returned by Start when WithSecure is combined with
+// WithGateway or WithWebProtocols, the shared port is served by the http
+// server which cannot use grpc transport credentials
+var ErrSecureSharedPort = errors.New("grpc server: WithSecure cannot be used on a shared port, configure TLS with WithHTTPServerOptions")
+
+// forwardedHeaders are passed from http requests to grpc metadata on top of
+// the grpc-gateway defaults
+var forwardedHeaders = map[string]bool{
+	"X-Request-Id":    true,
+	"Idempotency-Key": true,
+}
+
+// WithGateway serve grpc and a grpc-gateway REST mux on the same port.
+// Requests with HTTP/2 and an application/grpc content type go to the grpc
+// server, everything else to the http handlers. TLS is terminated by the
+// http server, configure it with WithHTTPServerOptions, WithSecure makes
+// Start fail with ErrSecureSharedPort.
+func WithGateway(fn GatewayRegisterFn, opts ...runtime.ServeMuxOption) Option {
+	return func(o *options) {
+		o.gatewayRegisterFn = fn
+		o.gatewayOptions = append(o.gatewayOptions, opts...)
+	}
+}
+
+// WithHTTPHandle mount handler on the shared port next to the gateway
+func WithHTTPHandle(pattern string, handler http.Handler) Option {
+	return func(o *options) {
+		if o.httpHandlers == nil {
+			o.httpHandlers = map[string]http.Handler{}
+		}
+		o.httpHandlers[pattern] = handler
+	}
+}
+
+// WithHTTPServerOptions set options of the http server serving the shared
+// port, e.g. TLS or middlewares
+func WithHTTPServerOptions(opts ...micro_http.Option) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, opts...)
+	}
+}
+
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if forwardedHeaders[key] {
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewaySpanName renames the http span after the gateway path pattern once it is known
+func gatewaySpanName(ctx context.Context, r *http.Request) metadata.MD {
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		trace.SpanFromContext(ctx).SetName(r.Method + " " + pattern)
+	}
+	return nil
+}
+
+// WithWebProtocols serve the gRPC-Web and Connect protocols for every
+// registered service on the shared port, requests are transcoded to grpc
+// and go through the same interceptors as native grpc calls
+func WithWebProtocols(cors micro_http.CORSConfig) Option {
+	return func(o *options) {
+		o.webEnabled = true
+		o.cors = cors
+	}
+}
+
+// grpcHandlerFunc routes native grpc requests to srv, gRPC-Web and Connect
+// requests for registered services to web and the rest to other
+func grpcHandlerFunc(srv *grpc.Server, web http.Handler, other http.Handler) http.Handler {
+	services := map[string]bool{}
+	for name := range srv.GetServiceInfo() {
+		services[name] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && isNativeGrpc(r.Header.Get("Content-Type")) {
+			srv.ServeHTTP(w, r)
+			return
+		}
+		if web != nil {
+			if service, _, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/"); ok && services[service] {
+				web.ServeHTTP(w, r)
+				return
+			}
+		}
+		other.ServeHTTP(w, r)
+	})
+}
+
+// isNativeGrpc excludes application/grpc-web which shares the prefix
+func isNativeGrpc(contentType string) bool {
+	return contentType == "application/grpc" || strings.HasPrefix(contentType, "application/grpc+")
+}
+
+// gateway serves grpc and http on one port
+type gateway struct {
+	httpServer *micro_http.Server
+	lis        *bufconn.Listener
+	conn       *grpc.ClientConn
+	err        error
+}
+
+func newGateway(srv *grpc.Server, o *options) *gateway {
+	g := &gateway{}
+
+	mux := http.NewServeMux()
+	for pattern, handler := range o.httpHandlers {
+		mux.Handle(pattern, handler)
+	}
+	if o.gatewayRegisterFn != nil {
+		mux.Handle("/", g.gatewayHandler(o))
+	}
+
+	var web http.Handler
+	if o.webEnabled {
+		transcoder, err := vanguardgrpc.NewTranscoder(srv)
+		if err != nil && g.err == nil {
+			g.err = err
+		}
+		web = micro_http.CORS(o.cors)(transcoder)
+	}
+
+	httpOptions := append([]micro_http.Option{
+		micro_http.WithPort(o.port),
+		micro_http.WithHandler(grpcHandlerFunc(srv, web, mux)),
+		micro_http.WithH2C(),
+		// grpc streams are long lived
+		micro_http.WithReadTimeout(0),
+		micro_http.WithWriteTimeout(0),
+	}, o.httpOptions...)
+	g.httpServer = micro_http.NewServer(httpOptions...)
+
+	if o.credentials != nil {
+		g.err = ErrSecureSharedPort
+	}
+	return g
+}
+
+// gatewayHandler returns the grpc-gateway mux calling srv in-process
+func (g *gateway) gatewayHandler(o *options) http.Handler {
+	g.lis = bufconn.Listen(1 << 20)
+	dialOptions := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return g.lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if o.tracingEnabled {
+		dialOptions = append(dialOptions, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	}
+	g.conn, g.err = grpc.NewClient("passthrough:///gateway", dialOptions...)
+
+	muxOptions := append([]runtime.ServeMuxOption{
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMetadata(gatewaySpanName),
+	}, o.gatewayOptions...)
+	gwmux := runtime.NewServeMux(muxOptions...)
+	if g.err == nil {
+		g.err = o.gatewayRegisterFn(context.Background(), gwmux, g.conn)
+	}
+
+	var handler http.Handler = gwmux
+	if o.tracingEnabled {
+		handler = micro_http.Tracing(nil)(handler)
+	}
+	return handler
+}
+
+func (g *gateway) start(srv *grpc.Server) error {
+	if g.err != nil {
+		return g.err
+	}
+	if g.lis != nil {
+		go srv.Serve(g.lis)
+	}
+	return g.httpServer.Start()
+}
+
+func (g *gateway) stop(srv *grpc.Server) error {
+	err := g.httpServer.Stop()
+	if g.conn != nil {
+		g.conn.Close()
+	}
+	srv.GracefulStop()
+	return err
+}
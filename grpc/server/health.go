@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/duolacloud/micro/health"
+	"google.golang.org/grpc"
+	grpc_health "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// WithHealth serves grpc_health_v1 with the readiness of h, every
+// registered service and the overall "" service report SERVING while all
+// checks pass. The state is refreshed every interval from Start on.
+// WithHealth is ignored when registerFn registers its own health service.
+func WithHealth(h *health.Health, interval time.Duration) Option {
+	return func(o *options) {
+		o.health = h
+		o.healthInterval = interval
+	}
+}
+
+// healthReporter mirrors a health.Health into a grpc health server
+type healthReporter struct {
+	health   *health.Health
+	server   *grpc_health.Server
+	services []string
+	interval time.Duration
+	cancel   context.CancelFunc
+}
+
+// newHealthReporter returns nil when srv already serves a health service
+func newHealthReporter(srv *grpc.Server, h *health.Health, interval time.Duration) *healthReporter {
+	if _, ok := srv.GetServiceInfo()[grpc_health_v1.Health_ServiceDesc.ServiceName]; ok {
+		return nil
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	r := &healthReporter{
+		health:   h,
+		server:   grpc_health.NewServer(),
+		interval: interval,
+	}
+	for name := range srv.GetServiceInfo() {
+		r.services = append(r.services, name)
+	}
+	grpc_health_v1.RegisterHealthServer(srv, r.server)
+
+	// not serving until the first check in start
+	r.setStatus(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	return r
+}
+
+func (r *healthReporter) update(ctx context.Context) {
+	status := grpc_health_v1.HealthCheckResponse_SERVING
+	if !r.health.Ready(ctx).OK() {
+		status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	r.setStatus(status)
+}
+
+func (r *healthReporter) setStatus(status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	r.server.SetServingStatus("", status)
+	for _, name := range r.services {
+		r.server.SetServingStatus(name, status)
+	}
+}
+
+func (r *healthReporter) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go func() {
+		r.update(ctx)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.update(ctx)
+			}
+		}
+	}()
+}
+
+// stop reports NOT_SERVING for every service so clients drain
+func (r *healthReporter) stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.health.Shutdown()
+	r.server.Shutdown()
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/duolacloud/micro/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpc_health "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// waitStatus polls the "" service until it reports want
+func waitStatus(t *testing.T, client grpc_health_v1.HealthClient, want grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	var got grpc_health_v1.HealthCheckResponse_ServingStatus
+	for i := 0; i < 100; i++ {
+		resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err == nil {
+			if got = resp.Status; got == want {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("status = %v, want %v", got, want)
+}
+
+func TestHealthReporter(t *testing.T) {
+	var ready atomic.Bool
+	ready.Store(true)
+	h := health.New(health.WithCacheTTL(0))
+	h.AddReadiness(health.NewChecker("db", func(ctx context.Context) error {
+		if !ready.Load() {
+			return errors.New("down")
+		}
+		return nil
+	}))
+
+	port := freePort(t)
+	srv := NewGrpcServer(func(*grpc.Server) {},
+		WithPort(port),
+		WithTracingEnabled(false),
+		WithHealth(h, 10*time.Millisecond),
+	)
+	go srv.Start()
+	defer srv.srv.Stop()
+
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	waitStatus(t, client, grpc_health_v1.HealthCheckResponse_SERVING)
+	ready.Store(false)
+	waitStatus(t, client, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	ready.Store(true)
+	waitStatus(t, client, grpc_health_v1.HealthCheckResponse_SERVING)
+
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	waitStatus(t, client, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestHealthKeepsRegisteredService(t *testing.T) {
+	own := grpc_health.NewServer()
+
+	// a second registration of the health service would be fatal
+	srv := NewGrpcServer(func(s *grpc.Server) {
+		grpc_health_v1.RegisterHealthServer(s, own)
+	}, WithTracingEnabled(false), WithHealth(health.New(), time.Second))
+
+	if srv.health != nil {
+		t.Fatal("health reporter installed over the registered health service")
+	}
+}
@@ -4,9 +4,15 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"time"
 
+	"github.com/duolacloud/micro/health"
+	micro_http "github.com/duolacloud/micro/http"
+	"github.com/duolacloud/micro/logging"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
@@ -32,6 +38,20 @@ type options struct {
 	serviceRegisterFn  ServiceRegisterFn
 	tracingEnabled     bool
 	serverMetrics      *grpc_prometheus.ServerMetrics
+	gatewayRegisterFn  GatewayRegisterFn
+	gatewayOptions     []runtime.ServeMuxOption
+	httpHandlers       map[string]http.Handler
+	httpOptions        []micro_http.Option
+	webEnabled         bool
+	cors               micro_http.CORSConfig
+	health             *health.Health
+	healthInterval     time.Duration
+	reflection         bool
+	channelz           bool
+	admin              bool
+	adminEnv           string
+	adminAllowList     []string
+	installLogger      func()
 }
 
 func defaultServerOptions() *options {
@@ -44,7 +64,8 @@ func (o *options) apply(opts ...Option) {
 	}
 }
 
-// WithSecure set secure
+// WithSecure set secure, it is not supported with WithGateway or
+// WithWebProtocols, see ErrSecureSharedPort
 func WithSecure(credential credentials.TransportCredentials) Option {
 	return func(o *options) {
 		o.credentials = credential
@@ -84,16 +105,33 @@ func WithServiceRegister(fn ServiceRegisterFn) Option {
 	}
 }
 
+// WithInternalLogger route grpc internal logging through logger. grpclog is
+// global, so this affects every server and client in the process and only
+// the first server or client created with this option installs its logger
+func WithInternalLogger(logger logging.Logger, opts ...logging.AdapterOption) Option {
+	return func(o *options) {
+		o.installLogger = func() {
+			logging.InstallGrpcLoggerOnce(logger, opts...)
+		}
+	}
+}
+
 func WithServerMetrics(metrics *grpc_prometheus.ServerMetrics) Option {
 	return func(o *options) {
 		o.serverMetrics = metrics
 	}
 }
 
+// sharedPort reports whether grpc and http are served on one port
+func (o *options) sharedPort() bool {
+	return o.gatewayRegisterFn != nil || o.webEnabled
+}
+
 func customInterceptorOptions(o *options) []grpc.ServerOption {
 	var opts []grpc.ServerOption
 
-	if o.credentials != nil {
+	// on a shared port the credentials are rejected by the gateway
+	if o.credentials != nil && !o.sharedPort() {
 		opts = append(opts, grpc.Creds(o.credentials))
 	}
 
@@ -128,11 +166,21 @@ func WithPort(port int) Option {
 }
 
 type GrpcServer struct {
-	srv  *grpc.Server
-	opts *options
+	srv     *grpc.Server
+	opts    *options
+	gateway *gateway
+	health  *healthReporter
+	cleanup func()
 }
 
 func (s *GrpcServer) Start() error {
+	if s.health != nil {
+		s.health.start()
+	}
+	if s.gateway != nil {
+		return s.gateway.start(s.srv)
+	}
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.opts.port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
@@ -149,6 +197,15 @@ func (s *GrpcServer) Start() error {
 }
 
 func (s *GrpcServer) Stop() error {
+	if s.health != nil {
+		s.health.stop()
+	}
+	if s.cleanup != nil {
+		defer s.cleanup()
+	}
+	if s.gateway != nil {
+		return s.gateway.stop(s.srv)
+	}
 	fmt.Println("GRPC Server stop")
 	return nil
 }
@@ -165,11 +222,18 @@ func NewGrpcServer(registerFn RegisterFn, options ...Option) *GrpcServer {
 	o := defaultServerOptions()
 	o.apply(options...)
 
+	// grpclog must be replaced before the server is created
+	if o.installLogger != nil {
+		o.installLogger()
+	}
+
 	srv := grpc.NewServer(customInterceptorOptions(o)...)
 
 	// register object to the server
 	registerFn(srv)
 
+	cleanup := registerAdmin(srv, o)
+
 	// register service to target
 	if o.serviceRegisterFn != nil {
 		o.serviceRegisterFn()
@@ -182,8 +246,21 @@ func NewGrpcServer(registerFn RegisterFn, options ...Option) *GrpcServer {
 		o.serverMetrics.InitializeMetrics(srv)
 	}
 
-	return &GrpcServer{
-		srv:  srv,
-		opts: o,
+	s := &GrpcServer{
+		srv:     srv,
+		opts:    o,
+		cleanup: cleanup,
 	}
+	if o.health != nil {
+		s.health = newHealthReporter(srv, o.health, o.healthInterval)
+	}
+	if o.sharedPort() {
+		s.gateway = newGateway(srv, o)
+	}
+	return s
+}
+
+// GetServiceInfo returns the registered services and their methods
+func (s *GrpcServer) GetServiceInfo() map[string]grpc.ServiceInfo {
+	return s.srv.GetServiceInfo()
 }
@@ -1 +1,175 @@
 package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	micro_http "github.com/duolacloud/micro/http"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func freePort(t *testing.T) int {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+func TestGateway(t *testing.T) {
+	port := freePort(t)
+	registerFn := func(srv *grpc.Server) {
+		grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	}
+	gatewayFn := func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+		client := grpc_health_v1.NewHealthClient(conn)
+		return mux.HandlePath(http.MethodGet, "/v1/health", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			resp, err := client.Check(r.Context(), &grpc_health_v1.HealthCheckRequest{})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(resp.Status.String()))
+		})
+	}
+
+	srv := NewGrpcServer(registerFn,
+		WithPort(port),
+		WithTracingEnabled(false),
+		WithGateway(gatewayFn),
+		WithHTTPHandle("/ping", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("pong"))
+		})),
+	)
+	go srv.Start()
+	defer srv.Stop()
+
+	addr := net.JoinHostPort("127.0.0.1", srv.Addr()[1:])
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + addr + "/v1/health"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "SERVING" {
+		t.Fatalf("gateway body = %q, want SERVING", body)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	check, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("grpc status = %v, want SERVING", check.Status)
+	}
+
+	if resp, err = http.Get("http://" + addr + "/ping"); err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Fatalf("http body = %q, want pong", body)
+	}
+}
+
+func TestWebProtocols(t *testing.T) {
+	port := freePort(t)
+	registerFn := func(srv *grpc.Server) {
+		grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	}
+	srv := NewGrpcServer(registerFn,
+		WithPort(port),
+		WithTracingEnabled(false),
+		WithWebProtocols(micro_http.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}),
+	)
+	go srv.Start()
+	defer srv.Stop()
+
+	url := "http://127.0.0.1:" + srv.Addr()[1:] + "/grpc.health.v1.Health/Check"
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Connect-Protocol-Version", "1")
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "SERVING") {
+		t.Fatalf("connect response = %d %s", resp.StatusCode, body)
+	}
+
+	req, _ := http.NewRequest(http.MethodOptions, url, nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("preflight = %d %v", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestAdminAllowList(t *testing.T) {
+	noop := func(srv *grpc.Server) {}
+
+	s := NewGrpcServer(noop, WithAdmin(), WithAdminAllowList("dev", "dev", "staging"))
+	services := s.GetServiceInfo()
+	for _, name := range []string{"grpc.reflection.v1.ServerReflection", "grpc.channelz.v1.Channelz"} {
+		if _, ok := services[name]; !ok {
+			t.Fatalf("%s not registered: %v", name, services)
+		}
+	}
+	s.Stop()
+
+	s = NewGrpcServer(noop, WithReflection(), WithChannelz(), WithAdminAllowList("prod", "dev"))
+	if services := s.GetServiceInfo(); len(services) != 0 {
+		t.Fatalf("expected no debug services in prod, got %v", services)
+	}
+}
+
+func TestSecureSharedPort(t *testing.T) {
+	srv := NewGrpcServer(func(*grpc.Server) {},
+		WithPort(freePort(t)),
+		WithTracingEnabled(false),
+		WithSecure(credentials.NewTLS(&tls.Config{})),
+		WithWebProtocols(micro_http.CORSConfig{}),
+	)
+	if err := srv.Start(); !errors.Is(err, ErrSecureSharedPort) {
+		t.Fatalf("Start err = %v, want ErrSecureSharedPort", err)
+	}
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// RedisChecker pings redis
+func RedisChecker(name string, client redis.UniversalClient) Checker {
+	return NewChecker(name, func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// GrpcChecker checks that conn can reach its target, an idle connection
+// is asked to connect and waited for until the check times out
+func GrpcChecker(name string, conn *grpc.ClientConn) Checker {
+	return NewChecker(name, func(ctx context.Context) error {
+		for {
+			state := conn.GetState()
+			switch state {
+			case connectivity.Ready:
+				return nil
+			case connectivity.Idle:
+				conn.Connect()
+			case connectivity.Shutdown:
+				return fmt.Errorf("grpc connection to %s is shut down", conn.Target())
+			}
+			if !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("grpc connection to %s is %s: %w", conn.Target(), state, ctx.Err())
+			}
+		}
+	})
+}
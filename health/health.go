@@ -0,0 +1,238 @@
+// Package health runs liveness and readiness checks and reports them over
+// http (/livez, /readyz, /healthz) and the grpc health protocol.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Checker checks one dependency or invariant
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c *checkerFunc) Name() string                    { return c.name }
+func (c *checkerFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// NewChecker returns a Checker calling fn
+func NewChecker(name string, fn func(ctx context.Context) error) Checker {
+	return &checkerFunc{name: name, fn: fn}
+}
+
+// Status of a check or of the aggregate
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// ErrShuttingDown is reported by readiness once Shutdown is called
+var ErrShuttingDown = errors.New("health: shutting down")
+
+// Result of a single check
+type Result struct {
+	Status    Status        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Report aggregates check results
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Option set health option
+type Option func(*options)
+
+type options struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+}
+
+func defaultOptions() *options {
+	return &options{
+		timeout:  time.Second,
+		cacheTTL: time.Second,
+	}
+}
+
+// WithTimeout set the timeout of each check
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithCacheTTL set how long check results are reused, so frequent probes do
+// not hammer dependencies
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.cacheTTL = ttl
+	}
+}
+
+type check struct {
+	checker Checker
+
+	mu     sync.Mutex
+	result Result
+	// running is closed when the in-flight run finishes
+	running chan struct{}
+}
+
+// run returns the cached result or waits for a run of the checker for at
+// most the timeout, concurrent callers share one run. A checker ignoring its
+// context keeps running in the background but no longer blocks probes.
+func (c *check) run(ctx context.Context, o *options) Result {
+	c.mu.Lock()
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < o.cacheTTL {
+		result := c.result
+		c.mu.Unlock()
+		return result
+	}
+	done := c.running
+	if done == nil {
+		done = make(chan struct{})
+		c.running = done
+		go c.exec(o, done)
+	}
+	c.mu.Unlock()
+
+	timer := time.NewTimer(o.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.result
+	case <-ctx.Done():
+		return Result{Status: StatusFail, Error: ctx.Err().Error(), CheckedAt: time.Now()}
+	case <-timer.C:
+		return Result{Status: StatusFail, Error: context.DeadlineExceeded.Error(), Duration: o.timeout, CheckedAt: time.Now()}
+	}
+}
+
+func (c *check) exec(o *options, done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	result := Result{Status: StatusOK, Duration: time.Since(start), CheckedAt: time.Now()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	c.result = result
+	c.running = nil
+	c.mu.Unlock()
+}
+
+// Health holds liveness and readiness checkers
+type Health struct {
+	opts         *options
+	mu           sync.RWMutex
+	liveness     []*check
+	readiness    []*check
+	shuttingDown atomic.Bool
+}
+
+// New returns an empty Health, it is live and ready until checkers are added
+func New(opts ...Option) *Health {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Health{opts: o}
+}
+
+// AddLiveness adds checkers telling whether the process must be restarted
+func (h *Health) AddLiveness(checkers ...Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range checkers {
+		h.liveness = append(h.liveness, &check{checker: c})
+	}
+}
+
+// AddReadiness adds checkers telling whether the process can take traffic
+func (h *Health) AddReadiness(checkers ...Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range checkers {
+		h.readiness = append(h.readiness, &check{checker: c})
+	}
+}
+
+// Shutdown makes readiness fail so traffic drains before the process stops
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live runs the liveness checks
+func (h *Health) Live(ctx context.Context) *Report {
+	h.mu.RLock()
+	checks := h.liveness
+	h.mu.RUnlock()
+	return h.run(ctx, checks)
+}
+
+// Ready runs the liveness and readiness checks
+func (h *Health) Ready(ctx context.Context) *Report {
+	h.mu.RLock()
+	checks := append(append([]*check(nil), h.liveness...), h.readiness...)
+	h.mu.RUnlock()
+
+	report := h.run(ctx, checks)
+	if h.shuttingDown.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = Result{Status: StatusFail, Error: ErrShuttingDown.Error(), CheckedAt: time.Now()}
+	}
+	return report
+}
+
+// run executes checks in parallel
+func (h *Health) run(ctx context.Context, checks []*check) *Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, h.opts)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.checker.Name()] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	var calls atomic.Int32
+	failing := atomic.Bool{}
+	h := New(WithCacheTTL(time.Hour))
+	h.AddLiveness(NewChecker("process", func(ctx context.Context) error { return nil }))
+	h.AddReadiness(NewChecker("db", func(ctx context.Context) error {
+		calls.Add(1)
+		if failing.Load() {
+			return errors.New("down")
+		}
+		return nil
+	}))
+
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+
+	for _, path := range []string{"/livez", "/readyz", "/healthz"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report Report
+		json.NewDecoder(resp.Body).Decode(&report)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || report.Status != StatusOK {
+			t.Fatalf("%s: got %d %s", path, resp.StatusCode, report.Status)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected cached result, checker ran %d times", calls.Load())
+	}
+
+	h.Shutdown()
+	resp, err := http.Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 after shutdown, got %d", resp.StatusCode)
+	}
+	if !h.Live(context.Background()).OK() {
+		t.Fatal("liveness should not depend on shutdown")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := New(WithTimeout(10*time.Millisecond), WithCacheTTL(0))
+	h.AddReadiness(
+		NewChecker("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		NewChecker("fast", func(ctx context.Context) error { return nil }),
+	)
+
+	report := h.Ready(context.Background())
+	if report.OK() {
+		t.Fatal("expected failure")
+	}
+	if report.Checks["slow"].Status != StatusFail || report.Checks["fast"].Status != StatusOK {
+		t.Fatalf("unexpected checks %+v", report.Checks)
+	}
+}
+
+func TestCheckerIgnoringContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var calls atomic.Int32
+
+	h := New(WithTimeout(20*time.Millisecond), WithCacheTTL(0))
+	h.AddReadiness(NewChecker("stuck", func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}))
+
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if h.Ready(context.Background()).OK() {
+			t.Fatal("expected failure")
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Fatalf("probe %d blocked for %v", i, elapsed)
+		}
+	}
+	// probes wait for the stuck run instead of piling up new ones
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("checker ran %d times", n)
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the CORS middleware, empty lists fall back to
+// defaults suited for gRPC-Web and Connect clients
+type CORSConfig struct {
+	// AllowedOrigins lists allowed origins, "*" allows any origin and is
+	// answered with a literal "*", so credentials are never allowed for it
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost}
+	defaultCORSHeaders = []string{
+		"Content-Type", "Authorization", "X-Request-Id", "Idempotency-Key",
+		"Connect-Protocol-Version", "Connect-Timeout-Ms", "Grpc-Timeout",
+		"X-Grpc-Web", "X-User-Agent",
+	}
+	defaultCORSExposedHeaders = []string{
+		"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin", "X-Request-Id",
+	}
+)
+
+// CORS answers preflight requests and sets CORS headers for allowed origins
+func CORS(config CORSConfig) Middleware {
+	methods := config.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := config.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	exposed := config.ExposedHeaders
+	if len(exposed) == 0 {
+		exposed = defaultCORSExposedHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(exposed, ", ")
+
+	wildcard := false
+	for _, o := range config.AllowedOrigins {
+		if o == "*" {
+			wildcard = true
+		}
+	}
+	allowed := func(origin string) bool {
+		if wildcard {
+			return true
+		}
+		for _, o := range config.AllowedOrigins {
+			if strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			// without a wildcard the headers depend on the origin, whether it
+			// is allowed or not, so caches must key on it
+			if !wildcard {
+				h.Add("Vary", "Origin")
+			}
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || !allowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if wildcard {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+				if config.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", allowMethods)
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+				if config.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.Set("Access-Control-Expose-Headers", exposeHeaders)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	serve := func(config CORSConfig, origin string) http.Header {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		CORS(config)(ok).ServeHTTP(rec, req)
+		return rec.Header()
+	}
+
+	listed := CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}
+	h := serve(listed, "https://app.example.com")
+	if h.Get("Access-Control-Allow-Origin") != "https://app.example.com" || h.Get("Access-Control-Allow-Credentials") != "true" || h.Get("Vary") != "Origin" {
+		t.Fatalf("allowed origin headers = %v", h)
+	}
+	h = serve(listed, "https://evil.example.com")
+	if h.Get("Access-Control-Allow-Origin") != "" || h.Get("Vary") != "Origin" {
+		t.Fatalf("disallowed origin headers = %v", h)
+	}
+
+	wildcard := CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+	h = serve(wildcard, "https://evil.example.com")
+	if h.Get("Access-Control-Allow-Origin") != "*" || h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("wildcard headers = %v", h)
+	}
+}
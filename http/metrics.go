@@ -0,0 +1,77 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type serverMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inflight prometheus.Gauge
+}
+
+func newServerMetrics(reg prometheus.Registerer) *serverMetrics {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	return &serverMetrics{
+		requests: registerCollector(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_server_requests_total",
+			Help: "Total number of http requests handled by the server.",
+		}, []string{"method", "route", "code"})),
+		duration: registerCollector(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_server_request_duration_seconds",
+			Help:    "Duration of http requests handled by the server.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "code"})),
+		inflight: registerCollector(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_server_requests_in_flight",
+			Help: "Number of http requests being handled by the server.",
+		})),
+	}
+}
+
+// registerCollector registers c or returns the collector already
+// registered, so several servers can share the metrics
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// Metrics records request rate, errors and duration by method, route and
+// status code, reg defaults to prometheus.DefaultRegisterer
+func Metrics(reg prometheus.Registerer, route RouteFunc) Middleware {
+	metrics := newServerMetrics(reg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			metrics.inflight.Inc()
+			defer metrics.inflight.Dec()
+
+			rw := newResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			labels := prometheus.Labels{
+				"method": r.Method,
+				"route":  routeOf(route, r),
+				"code":   strconv.Itoa(rw.status),
+			}
+			metrics.requests.With(labels).Inc()
+			metrics.duration.With(labels).Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/duolacloud/micro/logging"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps a handler
+type Middleware func(http.Handler) http.Handler
+
+// RouteFunc returns the route template of a request, e.g. "GET /items/{id}",
+// it is used for span names and metric labels so it must be low cardinality
+type RouteFunc func(r *http.Request) string
+
+// RequestIDHeader is the header carrying the request id
+const RequestIDHeader = "X-Request-Id"
+
+// Chain composes middlewares, the first one is the outermost
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// ServeMuxRoute resolves routes with the patterns registered on mux
+func ServeMuxRoute(mux *http.ServeMux) RouteFunc {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			return ""
+		}
+		if !strings.Contains(pattern, " ") {
+			pattern = r.Method + " " + pattern
+		}
+		return pattern
+	}
+}
+
+func routeOf(route RouteFunc, r *http.Request) string {
+	if route != nil {
+		if name := route(r); name != "" {
+			return name
+		}
+	}
+	return r.Method
+}
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request id set by the RequestID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID takes the request id from the X-Request-Id header or generates
+// one, puts it in the request context and the logging fields and echoes it
+// in the response
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+				r.Header.Set(RequestIDHeader, id)
+			}
+			w.Header().Set(RequestIDHeader, id)
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			ctx = logging.WithFields(ctx, zap.String("request_id", id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Tracing starts a server span named after the route template
+func Tracing(route RouteFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := RequestIDFromContext(r.Context()); id != "" {
+				trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.request_id", id))
+			}
+			next.ServeHTTP(w, r)
+		}), "http.server", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return routeOf(route, r)
+		}))
+	}
+}
+
+// AccessLog logs every request through logger
+func AccessLog(logger logging.Logger, route RouteFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := newResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			logger.InfoCtx(r.Context(), "http request",
+				zap.String("method", r.Method),
+				zap.String("route", routeOf(route, r)),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", rw.status),
+				zap.Int64("bytes", rw.bytes),
+				zap.Duration("elapsed", time.Since(start)),
+				zap.String("remote_addr", r.RemoteAddr),
+				zap.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
+
+// Recovery turns panics into 500 responses and logs them with the stack
+func Recovery(logger logging.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := newResponseWriter(w)
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				logger.ErrorCtx(r.Context(), "http handler panic",
+					zap.String("panic", fmt.Sprint(p)),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Stack("stack"),
+				)
+				if !rw.wroteHeader {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// responseWriter records the status and size of a response
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/duolacloud/micro/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := logging.NewLogger(zap.New(core))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	srv := NewServer(
+		WithHandler(mux),
+		WithRequestID(),
+		WithAccessLog(logger),
+		WithRecovery(logger),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("code = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != "req-1" {
+		t.Fatalf("request id = %q, want req-1", got)
+	}
+
+	if n := logs.FilterMessage("http handler panic").Len(); n != 1 {
+		t.Fatalf("panic logs = %d, want 1", n)
+	}
+	access := logs.FilterMessage("http request").All()
+	if len(access) != 1 {
+		t.Fatalf("access logs = %d, want 1", len(access))
+	}
+	fields := access[0].ContextMap()
+	if fields["route"] != "GET /items/{id}" || fields["status"] != int64(500) || fields["request_id"] != "req-1" {
+		t.Fatalf("access log fields = %v", fields)
+	}
+}
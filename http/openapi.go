@@ -0,0 +1,271 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//go:embed openapi.html
+var openAPIUI []byte
+
+// OpenAPIOption set openapi handler option
+type OpenAPIOption func(*openAPIOptions)
+
+type openAPIOptions struct {
+	title   string
+	version string
+}
+
+// WithOpenAPIInfo set the title and version of the merged document
+func WithOpenAPIInfo(title, version string) OpenAPIOption {
+	return func(o *openAPIOptions) {
+		o.title = title
+		o.version = version
+	}
+}
+
+// ReadOpenAPIDocs reads the documents matching patterns in fsys, typically
+// an embed.FS holding the generated *.swagger.json files
+func ReadOpenAPIDocs(fsys fs.FS, patterns ...string) ([][]byte, error) {
+	var names []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, matches...)
+	}
+	sort.Strings(names)
+
+	docs := make([][]byte, 0, len(names))
+	for _, name := range names {
+		doc, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// NewOpenAPIHandler merges docs into one document and serves it at
+// /openapi.json, with an embedded docs UI at /docs/. Documents must all be
+// Swagger 2.0 or all OpenAPI 3, entries defined by several documents must
+// be identical. Mount it under a prefix with http.StripPrefix.
+func NewOpenAPIHandler(docs [][]byte, opts ...OpenAPIOption) (http.Handler, error) {
+	o := &openAPIOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	merged, err := MergeOpenAPI(docs...)
+	if err != nil {
+		return nil, err
+	}
+	if o.title != "" || o.version != "" {
+		info, _ := merged["info"].(map[string]interface{})
+		if info == nil {
+			info = map[string]interface{}{}
+		}
+		if o.title != "" {
+			info["title"] = o.title
+		}
+		if o.version != "" {
+			info["version"] = o.version
+		}
+		merged["info"] = info
+	}
+	spec, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	})
+	mux.Handle("/docs", http.RedirectHandler("docs/", http.StatusMovedPermanently))
+	mux.HandleFunc("/docs/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(openAPIUI)
+	})
+	return mux, nil
+}
+
+// openAPISections are the named maps merged key by key for each version
+var openAPISections = map[string][]string{
+	"2": {"definitions", "parameters", "responses", "securityDefinitions"},
+	"3": {
+		"components.schemas", "components.responses", "components.parameters",
+		"components.examples", "components.requestBodies", "components.headers",
+		"components.securitySchemes", "components.links", "components.callbacks",
+	},
+}
+
+// MergeOpenAPI merges Swagger 2.0 or OpenAPI 3 documents. The first document
+// provides info, servers and security, paths and named definitions of all
+// documents are combined.
+func MergeOpenAPI(docs ...[]byte) (map[string]interface{}, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("openapi: no documents")
+	}
+
+	var merged map[string]interface{}
+	var version string
+	for i, data := range docs {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("openapi: document %d: %w", i, err)
+		}
+		v := openAPIVersion(doc)
+		if v == "" {
+			return nil, fmt.Errorf("openapi: document %d: missing swagger or openapi version", i)
+		}
+		if merged == nil {
+			merged, version = doc, v
+			continue
+		}
+		if v != version {
+			return nil, fmt.Errorf("openapi: document %d: cannot merge version %s into %s", i, v, version)
+		}
+
+		if err := mergePaths(merged, doc); err != nil {
+			return nil, fmt.Errorf("openapi: document %d: %w", i, err)
+		}
+		for _, section := range openAPISections[version] {
+			if err := mergeSection(merged, doc, section); err != nil {
+				return nil, fmt.Errorf("openapi: document %d: %w", i, err)
+			}
+		}
+		if tags := mergeTags(merged["tags"], doc["tags"]); len(tags) > 0 {
+			merged["tags"] = tags
+		}
+		if version == "2" {
+			for _, key := range []string{"consumes", "produces"} {
+				if values := mergeStrings(merged[key], doc[key]); len(values) > 0 {
+					merged[key] = values
+				}
+			}
+		}
+	}
+	return merged, nil
+}
+
+func openAPIVersion(doc map[string]interface{}) string {
+	if v, ok := doc["swagger"].(string); ok && strings.HasPrefix(v, "2") {
+		return "2"
+	}
+	if v, ok := doc["openapi"].(string); ok && strings.HasPrefix(v, "3") {
+		return "3"
+	}
+	return ""
+}
+
+// mergePaths merges path items method by method
+func mergePaths(dst, src map[string]interface{}) error {
+	srcPaths, _ := src["paths"].(map[string]interface{})
+	if len(srcPaths) == 0 {
+		return nil
+	}
+	dstPaths, _ := dst["paths"].(map[string]interface{})
+	if dstPaths == nil {
+		dstPaths = map[string]interface{}{}
+		dst["paths"] = dstPaths
+	}
+	for path, item := range srcPaths {
+		srcItem, _ := item.(map[string]interface{})
+		dstItem, ok := dstPaths[path].(map[string]interface{})
+		if !ok {
+			dstPaths[path] = item
+			continue
+		}
+		if err := mergeMap(dstItem, srcItem, "paths."+path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeSection merges the map at the dotted section path
+func mergeSection(dst, src map[string]interface{}, section string) error {
+	keys := strings.Split(section, ".")
+	srcMap := lookupMap(src, keys, false)
+	if len(srcMap) == 0 {
+		return nil
+	}
+	return mergeMap(lookupMap(dst, keys, true), srcMap, section)
+}
+
+func lookupMap(doc map[string]interface{}, keys []string, create bool) map[string]interface{} {
+	m := doc
+	for _, key := range keys {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			if !create {
+				return nil
+			}
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	return m
+}
+
+func mergeMap(dst, src map[string]interface{}, section string) error {
+	for key, value := range src {
+		if existing, ok := dst[key]; ok {
+			if !reflect.DeepEqual(existing, value) {
+				return fmt.Errorf("conflicting definitions of %s.%s", section, key)
+			}
+			continue
+		}
+		dst[key] = value
+	}
+	return nil
+}
+
+func mergeTags(dst, src interface{}) []interface{} {
+	dstTags, _ := dst.([]interface{})
+	srcTags, _ := src.([]interface{})
+	seen := map[string]bool{}
+	for _, tag := range dstTags {
+		if m, ok := tag.(map[string]interface{}); ok {
+			seen[fmt.Sprint(m["name"])] = true
+		}
+	}
+	for _, tag := range srcTags {
+		m, ok := tag.(map[string]interface{})
+		if !ok || seen[fmt.Sprint(m["name"])] {
+			continue
+		}
+		seen[fmt.Sprint(m["name"])] = true
+		dstTags = append(dstTags, tag)
+	}
+	return dstTags
+}
+
+func mergeStrings(dst, src interface{}) []interface{} {
+	dstValues, _ := dst.([]interface{})
+	srcValues, _ := src.([]interface{})
+	for _, v := range srcValues {
+		found := false
+		for _, e := range dstValues {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dstValues = append(dstValues, v)
+		}
+	}
+	return dstValues
+}
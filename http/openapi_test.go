@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const helloDoc = `{
+  "swagger": "2.0",
+  "info": {"title": "hello.proto", "version": "version not set"},
+  "tags": [{"name": "HelloService"}],
+  "paths": {"/v1/hello": {"post": {"operationId": "SayHello"}}},
+  "definitions": {"rpcStatus": {"type": "object"}}
+}`
+
+const orderDoc = `{
+  "swagger": "2.0",
+  "info": {"title": "order.proto", "version": "version not set"},
+  "tags": [{"name": "OrderService"}],
+  "paths": {"/v1/hello": {"get": {"operationId": "GetHello"}}, "/v1/orders": {"post": {"operationId": "CreateOrder"}}},
+  "definitions": {"rpcStatus": {"type": "object"}}
+}`
+
+func TestMergeOpenAPI(t *testing.T) {
+	merged, err := MergeOpenAPI([]byte(helloDoc), []byte(orderDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := merged["paths"].(map[string]interface{})
+	if len(paths) != 2 || len(paths["/v1/hello"].(map[string]interface{})) != 2 {
+		t.Fatalf("paths = %v", paths)
+	}
+	if tags := merged["tags"].([]interface{}); len(tags) != 2 {
+		t.Fatalf("tags = %v", tags)
+	}
+
+	conflict := strings.Replace(orderDoc, `"rpcStatus": {"type": "object"}`, `"rpcStatus": {"type": "string"}`, 1)
+	if _, err := MergeOpenAPI([]byte(helloDoc), []byte(conflict)); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if _, err := MergeOpenAPI([]byte(helloDoc), []byte(`{"openapi": "3.0.0"}`)); err == nil {
+		t.Fatal("expected version error")
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	handler, err := NewOpenAPIHandler([][]byte{[]byte(helloDoc), []byte(orderDoc)}, WithOpenAPIInfo("Shop", "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var spec map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if info := spec["info"].(map[string]interface{}); info["title"] != "Shop" || info["version"] != "v1" {
+		t.Fatalf("info = %v", info)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+	if !strings.Contains(rec.Body.String(), "../openapi.json") || strings.Contains(rec.Body.String(), "https://") {
+		t.Fatal("docs UI must load the spec locally without a CDN")
+	}
+}
@@ -1,16 +1,47 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"time"
+
+	"github.com/duolacloud/micro/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Option set server option
 type Option func(*options)
 
 type options struct {
-	handler http.Handler
-	port    int
+	handler           http.Handler
+	host              string
+	port              int
+	listener          net.Listener
+	shutdownTimeout   time.Duration
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	tlsConfig         *tls.Config
+	certFile          string
+	keyFile           string
+	http2Disabled     bool
+	h2c               bool
+	middlewares       []Middleware
+	route             RouteFunc
+	requestID         bool
+	tracingEnabled    bool
+	metricsEnabled    bool
+	registerer        prometheus.Registerer
+	accessLogger      logging.Logger
+	recoveryLogger    logging.Logger
 }
 
 func (o *options) apply(opts ...Option) {
@@ -25,38 +56,288 @@ func WithPort(port int) Option {
 	}
 }
 
+// WithHost set the host to bind, default is all interfaces
+func WithHost(host string) Option {
+	return func(o *options) {
+		o.host = host
+	}
+}
+
+// WithListener serve on a pre-opened listener instead of host and port
+func WithListener(listener net.Listener) Option {
+	return func(o *options) {
+		o.listener = listener
+	}
+}
+
 func WithHandler(handler http.Handler) Option {
 	return func(o *options) {
 		o.handler = handler
 	}
 }
 
+// WithShutdownTimeout set how long Stop waits for connections to drain
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithReadTimeout set the maximum duration for reading the entire request
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = timeout
+	}
+}
+
+// WithReadHeaderTimeout set the maximum duration for reading request headers
+func WithReadHeaderTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readHeaderTimeout = timeout
+	}
+}
+
+// WithWriteTimeout set the maximum duration before timing out writes of the response
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = timeout
+	}
+}
+
+// WithIdleTimeout set how long keep-alive connections are kept idle
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithMaxHeaderBytes set the maximum size of request headers
+func WithMaxHeaderBytes(n int) Option {
+	return func(o *options) {
+		o.maxHeaderBytes = n
+	}
+}
+
+// WithTLSConfig serve TLS with config
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithTLSCertFile serve TLS with the key pair in certFile and keyFile,
+// the files are reloaded when they change
+func WithTLSCertFile(certFile, keyFile string) Option {
+	return func(o *options) {
+		o.certFile = certFile
+		o.keyFile = keyFile
+	}
+}
+
+// WithHTTP2 enable or disable HTTP/2 over TLS, enabled by default
+func WithHTTP2(enabled bool) Option {
+	return func(o *options) {
+		o.http2Disabled = !enabled
+	}
+}
+
+// WithH2C serve HTTP/2 without TLS
+func WithH2C() Option {
+	return func(o *options) {
+		o.h2c = true
+	}
+}
+
+// WithMiddleware add middlewares around the handler, the first one is the
+// outermost. Built-in middlewares always wrap them in this order:
+// request id, tracing, metrics, access log, recovery.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithRouteFunc set how route templates are resolved for span names and
+// metric labels, default uses the patterns of a *http.ServeMux handler
+func WithRouteFunc(route RouteFunc) Option {
+	return func(o *options) {
+		o.route = route
+	}
+}
+
+// WithRequestID generate or propagate the X-Request-Id header
+func WithRequestID() Option {
+	return func(o *options) {
+		o.requestID = true
+	}
+}
+
+func WithTracingEnabled(tracingEnabled bool) Option {
+	return func(o *options) {
+		o.tracingEnabled = tracingEnabled
+	}
+}
+
+// WithMetrics record RED metrics to registerer, nil means prometheus.DefaultRegisterer
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(o *options) {
+		o.metricsEnabled = true
+		o.registerer = registerer
+	}
+}
+
+// WithAccessLog log every request through logger
+func WithAccessLog(logger logging.Logger) Option {
+	return func(o *options) {
+		o.accessLogger = logger
+	}
+}
+
+// WithRecovery recover handler panics and log them through logger
+func WithRecovery(logger logging.Logger) Option {
+	return func(o *options) {
+		o.recoveryLogger = logger
+	}
+}
+
 func defaultServerOptions() *options {
 	return &options{
-		port: 8080,
+		port:              8080,
+		shutdownTimeout:   15 * time.Second,
+		readTimeout:       30 * time.Second,
+		readHeaderTimeout: 5 * time.Second,
+		writeTimeout:      60 * time.Second,
+		idleTimeout:       120 * time.Second,
+		maxHeaderBytes:    http.DefaultMaxHeaderBytes,
 	}
 }
 
 type Server struct {
 	opts *options
+	srv  *http.Server
 }
 
+// Start serves until the server is stopped, a stopped server is not an error
 func (s *Server) Start() error {
-	fmt.Printf("Http server start..., port: %v\n", s.opts.port)
+	if s.srv.TLSConfig != nil && s.srv.TLSConfig.GetCertificate != nil {
+		// fail fast on a missing or broken key pair
+		if _, err := s.srv.TLSConfig.GetCertificate(nil); err != nil {
+			return err
+		}
+	}
+
+	lis := s.opts.listener
+	if lis == nil {
+		var err error
+		lis, err = net.Listen("tcp", s.srv.Addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Http server start..., addr: %v\n", lis.Addr())
 
-	return http.ListenAndServe(fmt.Sprintf(":%v", s.opts.port), s.opts.handler)
+	var err error
+	if s.srv.TLSConfig != nil {
+		err = s.srv.ServeTLS(lis, "", "")
+	} else {
+		err = s.srv.Serve(lis)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
+// Stop gracefully shuts down the server within the shutdown timeout
 func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.shutdownTimeout)
+	defer cancel()
+
+	return s.StopContext(ctx)
+}
+
+// StopContext gracefully shuts down the server, connections still active
+// when ctx is done are closed
+func (s *Server) StopContext(ctx context.Context) error {
 	fmt.Println("Http server stop")
+
+	if err := s.srv.Shutdown(ctx); err != nil {
+		_ = s.srv.Close()
+		return err
+	}
 	return nil
 }
 
+// RegisterOnShutdown registers a function to call on Stop
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.srv.RegisterOnShutdown(f)
+}
+
 func NewServer(options ...Option) *Server {
 	o := defaultServerOptions()
 	o.apply(options...)
 
+	handler := o.handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	route := o.route
+	if mux, ok := handler.(*http.ServeMux); ok && route == nil {
+		route = ServeMuxRoute(mux)
+	}
+
+	var middlewares []Middleware
+	if o.requestID {
+		middlewares = append(middlewares, RequestID())
+	}
+	if o.tracingEnabled {
+		middlewares = append(middlewares, Tracing(route))
+	}
+	if o.metricsEnabled {
+		middlewares = append(middlewares, Metrics(o.registerer, route))
+	}
+	if o.accessLogger != nil {
+		middlewares = append(middlewares, AccessLog(o.accessLogger, route))
+	}
+	if o.recoveryLogger != nil {
+		middlewares = append(middlewares, Recovery(o.recoveryLogger))
+	}
+	middlewares = append(middlewares, o.middlewares...)
+	handler = Chain(middlewares...)(handler)
+
+	if o.h2c {
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: o.idleTimeout})
+	}
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(o.host, fmt.Sprint(o.port)),
+		Handler:           handler,
+		ReadTimeout:       o.readTimeout,
+		ReadHeaderTimeout: o.readHeaderTimeout,
+		WriteTimeout:      o.writeTimeout,
+		IdleTimeout:       o.idleTimeout,
+		MaxHeaderBytes:    o.maxHeaderBytes,
+	}
+
+	if o.tlsConfig != nil || o.certFile != "" {
+		config := o.tlsConfig
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		} else {
+			config = config.Clone()
+		}
+		if o.certFile != "" {
+			config.GetCertificate = newCertReloader(o.certFile, o.keyFile).GetCertificate
+		}
+		srv.TLSConfig = config
+	}
+	if o.http2Disabled {
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
 	return &Server{
-		o,
+		opts: o,
+		srv:  srv,
 	}
 }
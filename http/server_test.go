@@ -0,0 +1,166 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+// get retries until the server started by Start accepts connections
+func get(t *testing.T, url string) *http.Response {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			return resp
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerStartStop(t *testing.T) {
+	port := freePort(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	srv := NewServer(WithPort(port), WithHandler(mux), WithShutdownTimeout(time.Second))
+
+	shutdown := make(chan struct{})
+	srv.RegisterOnShutdown(func() { close(shutdown) })
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Start() }()
+
+	resp := get(t, fmt.Sprintf("http://127.0.0.1:%d/ping", port))
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Fatalf("body = %q, want pong", body)
+	}
+
+	if err := srv.Stop(); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Start() = %v, want nil after Stop", err)
+	}
+	select {
+	case <-shutdown:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown hook not called")
+	}
+}
+
+func TestServerDefaults(t *testing.T) {
+	srv := NewServer(WithHost("127.0.0.1"), WithPort(9090)).srv
+
+	if srv.Addr != "127.0.0.1:9090" {
+		t.Errorf("Addr = %q, want 127.0.0.1:9090", srv.Addr)
+	}
+	if srv.ReadTimeout != 30*time.Second || srv.ReadHeaderTimeout != 5*time.Second ||
+		srv.WriteTimeout != 60*time.Second || srv.IdleTimeout != 120*time.Second {
+		t.Errorf("timeouts = %v %v %v %v", srv.ReadTimeout, srv.ReadHeaderTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+	if srv.MaxHeaderBytes != http.DefaultMaxHeaderBytes {
+		t.Errorf("MaxHeaderBytes = %d", srv.MaxHeaderBytes)
+	}
+}
+
+func TestServerHost(t *testing.T) {
+	port := freePort(t)
+	srv := NewServer(WithHost("127.0.0.1"), WithPort(port), WithHandler(http.NotFoundHandler()))
+	go srv.Start()
+	defer srv.Stop()
+
+	resp := get(t, fmt.Sprintf("http://127.0.0.1:%d/", port))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+}
+
+// protoServer starts a server answering with the request protocol
+func protoServer(t *testing.T, opts ...Option) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+	srv := NewServer(append([]Option{WithListener(lis), WithHandler(handler)}, opts...)...)
+	go srv.Start()
+	t.Cleanup(func() { srv.Stop() })
+	return lis.Addr().String()
+}
+
+func proto(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestServerH2C(t *testing.T) {
+	addr := protoServer(t, WithH2C())
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	if got := proto(t, client, "http://"+addr); got != "HTTP/2.0" {
+		t.Fatalf("proto = %s, want HTTP/2.0", got)
+	}
+}
+
+func TestServerHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeKeyPair(t, certFile, keyFile, 1)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+
+	addr := protoServer(t, WithTLSCertFile(certFile, keyFile))
+	if got := proto(t, client, "https://"+addr); got != "HTTP/2.0" {
+		t.Fatalf("proto = %s, want HTTP/2.0 by default", got)
+	}
+
+	addr = protoServer(t, WithTLSCertFile(certFile, keyFile), WithHTTP2(false))
+	if got := proto(t, client, "https://"+addr); got != "HTTP/1.1" {
+		t.Fatalf("proto = %s, want HTTP/1.1 with WithHTTP2(false)", got)
+	}
+}
@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// UnaryServerInterceptor deduplicates unary calls carrying the
+// idempotency-key metadata. Calls without the key pass through untouched.
+// A failed call releases its key so the client can retry it.
+func UnaryServerInterceptor(store Store, opts ...Option) grpc.UnaryServerInterceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := keyFromMetadata(ctx)
+		if key == "" {
+			return handler(ctx, req)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		key = info.FullMethod + ":" + key
+		fp := fingerprint([]byte(info.FullMethod), payload)
+
+		record, err := store.Begin(ctx, key, fp, o.lockTTL)
+		switch {
+		case errors.Is(err, ErrInProgress):
+			return nil, status.Error(codes.Aborted, err.Error())
+		case errors.Is(err, ErrFingerprintMismatch):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case err != nil:
+			return nil, status.Error(codes.Unavailable, err.Error())
+		case record != nil:
+			return replayMessage(record.Response)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			release(ctx, store, o, key, fp)
+			return resp, err
+		}
+
+		data, err := marshalMessage(resp)
+		if err != nil {
+			release(ctx, store, o, key, fp)
+			return resp, nil
+		}
+		complete(ctx, store, o, key, fp, data)
+
+		return resp, nil
+	}
+}
+
+func keyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(MetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func marshalMessage(resp interface{}) ([]byte, error) {
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return nil, errors.New("idempotency: response is not a proto message")
+	}
+	a, err := anypb.New(msg)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(a)
+}
+
+func replayMessage(data []byte) (interface{}, error) {
+	var a anypb.Any
+	if err := proto.Unmarshal(data, &a); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	msg, err := a.UnmarshalNew()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return msg, nil
+}
@@ -0,0 +1,82 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	store := &memStore{records: map[string]*Record{}}
+	interceptor := UnaryServerInterceptor(store)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Create"}
+
+	calls := 0
+	var handlerErr error
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		if handlerErr != nil {
+			return nil, handlerErr
+		}
+		return wrapperspb.String("order-" + req.(*wrapperspb.StringValue).GetValue()), nil
+	}
+	call := func(key, payload string) (interface{}, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, key))
+		return interceptor(ctx, wrapperspb.String(payload), info, handler)
+	}
+
+	first, err := call("k1", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := call("k1", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if !proto.Equal(first.(proto.Message), second.(proto.Message)) {
+		t.Fatalf("replayed %v, want %v", second, first)
+	}
+
+	if _, err := call("k1", "b"); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("mismatched payload err = %v", err)
+	}
+
+	// a failed call releases the key
+	handlerErr = errors.New("boom")
+	if _, err := call("k2", "a"); err != handlerErr {
+		t.Fatalf("err = %v", err)
+	}
+	handlerErr = nil
+	if _, err := call("k2", "a"); err != nil || calls != 3 {
+		t.Fatalf("retry err = %v, calls = %d", err, calls)
+	}
+}
+
+func TestUnaryServerInterceptorInProgress(t *testing.T) {
+	store := &memStore{records: map[string]*Record{}}
+	interceptor := UnaryServerInterceptor(store)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Create"}
+
+	payload, _ := proto.MarshalOptions{Deterministic: true}.Marshal(wrapperspb.String("a"))
+	store.Begin(context.Background(), info.FullMethod+":k1", fingerprint([]byte(info.FullMethod), payload), time.Minute)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k1"))
+	_, err := interceptor(ctx, wrapperspb.String("a"), info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("handler ran while the key is in progress")
+		return nil, nil
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package idempotency
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type httpResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Middleware deduplicates http requests carrying the Idempotency-Key header.
+// Concurrent duplicates get 409 Conflict, a key reused with another payload
+// gets 422 Unprocessable Entity and completed duplicates get the stored
+// response replayed. 5xx responses release the key so the client can retry.
+func Middleware(store Store, opts ...Option) func(http.Handler) http.Handler {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(o.header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, o.maxBody))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			key = r.Method + " " + r.URL.Path + ":" + key
+			fp := fingerprint([]byte(r.Method), []byte(r.URL.RequestURI()), body)
+
+			record, err := store.Begin(ctx, key, fp, o.lockTTL)
+			switch {
+			case errors.Is(err, ErrInProgress):
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case errors.Is(err, ErrFingerprintMismatch):
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			case record != nil:
+				replayResponse(w, record.Response)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				if p := recover(); p != nil {
+					release(ctx, store, o, key, fp)
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= http.StatusInternalServerError {
+				release(ctx, store, o, key, fp)
+				return
+			}
+			data, err := json.Marshal(&httpResponse{
+				Status: rec.status,
+				Header: w.Header().Clone(),
+				Body:   rec.body.Bytes(),
+			})
+			if err != nil {
+				release(ctx, store, o, key, fp)
+				return
+			}
+			complete(ctx, store, o, key, fp, data)
+		})
+	}
+}
+
+func replayResponse(w http.ResponseWriter, data []byte) {
+	var resp httpResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
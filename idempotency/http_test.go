@@ -0,0 +1,143 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/duolacloud/micro/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+}
+
+func (s *memStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[key]
+	if !ok {
+		s.records[key] = &Record{State: StateInProgress, Fingerprint: fingerprint}
+		return nil, nil
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+	if record.State != StateCompleted {
+		return nil, ErrInProgress
+	}
+	return record, nil
+}
+
+func (s *memStore) owned(key, fingerprint string) bool {
+	record, ok := s.records[key]
+	return ok && record.State == StateInProgress && record.Fingerprint == fingerprint
+}
+
+func (s *memStore) Complete(ctx context.Context, key, fingerprint string, response []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.owned(key, fingerprint) {
+		return ErrLockLost
+	}
+	s.records[key] = &Record{State: StateCompleted, Fingerprint: fingerprint, Response: response}
+	return nil
+}
+
+func (s *memStore) Release(ctx context.Context, key, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owned(key, fingerprint) {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	calls := 0
+	handler := Middleware(&memStore{records: map[string]*Record{}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Order", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req.Header.Set(HeaderKey, "k1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := do("a")
+	second := do("a")
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != "created" || second.Header().Get("X-Order") != "1" {
+		t.Fatalf("replayed response = %d %q, want %d %q", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+
+	if rec := do("b"); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("mismatched payload code = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	store := &memStore{records: map[string]*Record{}}
+	handler := Middleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	store.Begin(context.Background(), "POST /orders:k1", fingerprint([]byte(http.MethodPost), []byte("/orders"), nil), time.Minute)
+	req.Header.Set(HeaderKey, "k1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("code = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestMiddlewareBodyLimit(t *testing.T) {
+	calls := 0
+	handler := Middleware(&memStore{records: map[string]*Record{}}, WithMaxBodySize(4))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("too large"))
+	req.Header.Set(HeaderKey, "k1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge || calls != 0 {
+		t.Fatalf("code = %d, calls = %d", rec.Code, calls)
+	}
+}
+
+func TestMiddlewareLogsLostLock(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	store := &memStore{records: map[string]*Record{}}
+	handler := Middleware(store, WithLogger(logging.NewLogger(zap.New(core))))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the marker expired and another request took the key over
+		store.records["POST /orders:k1"] = &Record{State: StateInProgress, Fingerprint: "other"}
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(HeaderKey, "k1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if store.records["POST /orders:k1"].Fingerprint != "other" {
+		t.Fatal("record of the other request overwritten")
+	}
+	entries := logs.FilterMessage("idempotency: complete key failed").All()
+	if len(entries) != 1 || entries[0].ContextMap()["error"] != ErrLockLost.Error() {
+		t.Fatalf("entries = %+v", logs.All())
+	}
+}
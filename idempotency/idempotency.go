@@ -0,0 +1,158 @@
+// Package idempotency deduplicates retried requests on the server side.
+//
+// A request carrying an idempotency key is recorded in a Store as in
+// progress before the handler runs and as completed, together with its
+// response, once the handler succeeds. Concurrent duplicates are rejected
+// and completed duplicates get the stored response replayed.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/duolacloud/micro/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// HeaderKey is the http header carrying the idempotency key
+	HeaderKey = "Idempotency-Key"
+	// MetadataKey is the grpc metadata key carrying the idempotency key
+	MetadataKey = "idempotency-key"
+)
+
+var (
+	// ErrInProgress is returned when a request with the same key is still being handled
+	ErrInProgress = errors.New("idempotency: request in progress")
+	// ErrFingerprintMismatch is returned when a key is reused with a different payload
+	ErrFingerprintMismatch = errors.New("idempotency: key reused with different payload")
+	// ErrLockLost is returned by Complete when the in-progress marker expired
+	// or was taken over by another request
+	ErrLockLost = errors.New("idempotency: in-progress marker lost")
+)
+
+// State of a recorded request
+type State string
+
+const (
+	StateInProgress State = "in_progress"
+	StateCompleted  State = "completed"
+)
+
+// Record is what a Store keeps for an idempotency key
+type Record struct {
+	State       State  `json:"state"`
+	Fingerprint string `json:"fingerprint"`
+	Response    []byte `json:"response,omitempty"`
+}
+
+// Store persists idempotency records
+type Store interface {
+	// Begin marks key as in progress. When the key already exists it returns
+	// the completed record, ErrInProgress or ErrFingerprintMismatch.
+	// A nil record and nil error means the caller owns the key.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+	// Complete stores the final response for key while the in-progress
+	// marker of fingerprint is still there, otherwise it returns ErrLockLost
+	Complete(ctx context.Context, key, fingerprint string, response []byte, ttl time.Duration) error
+	// Release forgets the in-progress marker of fingerprint so the request
+	// can be retried, records of other requests are kept
+	Release(ctx context.Context, key, fingerprint string) error
+}
+
+// Option set idempotency option
+type Option func(*options)
+
+type options struct {
+	ttl     time.Duration
+	lockTTL time.Duration
+	header  string
+	maxBody int64
+	logger  logging.Logger
+}
+
+func defaultOptions() *options {
+	return &options{
+		ttl:     24 * time.Hour,
+		lockTTL: time.Minute,
+		header:  HeaderKey,
+		maxBody: 1 << 20,
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithTTL set how long completed responses are kept
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithLockTTL set how long the in-progress marker lives, it bounds how long
+// a crashed handler blocks retries
+func WithLockTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.lockTTL = ttl
+	}
+}
+
+// WithHeader set the http header carrying the key
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithMaxBodySize set the largest http request body fingerprinted, larger
+// requests carrying a key get 413, default is 1MB
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBody = n
+	}
+}
+
+// WithLogger set logger reporting store failures after the handler ran,
+// default is logging.FromContext
+func WithLogger(logger logging.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// complete stores the response, a failure only costs the replay of duplicates
+func complete(ctx context.Context, store Store, o *options, key, fp string, response []byte) {
+	if err := store.Complete(context.WithoutCancel(ctx), key, fp, response, o.ttl); err != nil {
+		o.loggerFor(ctx).ErrorCtx(ctx, "idempotency: complete key failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// release forgets key, a failure blocks retries until the marker expires
+func release(ctx context.Context, store Store, o *options, key, fp string) {
+	if err := store.Release(context.WithoutCancel(ctx), key, fp); err != nil {
+		o.loggerFor(ctx).WarnCtx(ctx, "idempotency: release key failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (o *options) loggerFor(ctx context.Context) logging.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return logging.FromContext(ctx)
+}
+
+func fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
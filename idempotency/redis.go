@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// completeScript stores the completed record only while the caller's
+// in-progress marker is still there
+var completeScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// releaseScript deletes the caller's in-progress marker only
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call("DEL", KEYS[1])
+`)
+
+type redisStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisStore returns a Store keeping records in redis under prefix
+func NewRedisStore(client redis.Cmdable, prefix string) Store {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func marker(fingerprint string) ([]byte, error) {
+	return json.Marshal(&Record{State: StateInProgress, Fingerprint: fingerprint})
+}
+
+func (s *redisStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	marker, err := marker(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	// the record may expire between SETNX and GET, so try twice
+	for i := 0; i < 2; i++ {
+		ok, err := s.client.SetNX(ctx, s.prefix+key, marker, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return nil, nil
+		}
+
+		data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+		if record.Fingerprint != fingerprint {
+			return nil, ErrFingerprintMismatch
+		}
+		if record.State != StateCompleted {
+			return nil, ErrInProgress
+		}
+		return &record, nil
+	}
+
+	return nil, ErrInProgress
+}
+
+func (s *redisStore) Complete(ctx context.Context, key, fingerprint string, response []byte, ttl time.Duration) error {
+	marker, err := marker(fingerprint)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&Record{State: StateCompleted, Fingerprint: fingerprint, Response: response})
+	if err != nil {
+		return err
+	}
+	ok, err := completeScript.Run(ctx, s.client, []string{s.prefix + key}, marker, data, ttl.Milliseconds()).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (s *redisStore) Release(ctx context.Context, key, fingerprint string) error {
+	marker, err := marker(fingerprint)
+	if err != nil {
+		return err
+	}
+	return releaseScript.Run(ctx, s.client, []string{s.prefix + key}, marker).Err()
+}
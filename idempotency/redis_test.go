@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	store := NewRedisStore(client, "idem:")
+
+	record, err := store.Begin(ctx, "k1", "fp", time.Minute)
+	if record != nil || err != nil {
+		t.Fatalf("first Begin = %v, %v", record, err)
+	}
+	if !mr.Exists("idem:k1") {
+		t.Fatalf("keys = %v", mr.Keys())
+	}
+	if _, err := store.Begin(ctx, "k1", "fp", time.Minute); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("in progress Begin err = %v", err)
+	}
+	if _, err := store.Begin(ctx, "k1", "other", time.Minute); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("mismatched Begin err = %v", err)
+	}
+
+	if err := store.Complete(ctx, "k1", "fp", []byte("response"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	record, err = store.Begin(ctx, "k1", "fp", time.Minute)
+	if err != nil || record == nil || record.State != StateCompleted || string(record.Response) != "response" {
+		t.Fatalf("completed Begin = %+v, %v", record, err)
+	}
+	if ttl := mr.TTL("idem:k1"); ttl != time.Hour {
+		t.Fatalf("completed ttl = %v", ttl)
+	}
+
+	// only the owner of the in-progress marker completes or releases it
+	if err := store.Release(ctx, "k1", "fp"); err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("idem:k1") {
+		t.Fatal("Release removed a completed record")
+	}
+	mr.FastForward(time.Hour)
+	if record, err := store.Begin(ctx, "k1", "new", time.Minute); record != nil || err != nil {
+		t.Fatalf("Begin after expiry = %v, %v", record, err)
+	}
+	if err := store.Complete(ctx, "k1", "fp", []byte("stale"), time.Hour); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("Complete by a former owner err = %v, want %v", err, ErrLockLost)
+	}
+	if err := store.Release(ctx, "k1", "fp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Begin(ctx, "k1", "new", time.Minute); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("Begin err = %v, a former owner must not release the marker", err)
+	}
+	if err := store.Release(ctx, "k1", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if record, err := store.Begin(ctx, "k1", "other", time.Minute); record != nil || err != nil {
+		t.Fatalf("Begin after Release = %v, %v", record, err)
+	}
+
+	// an expired in-progress marker no longer blocks retries
+	mr.FastForward(time.Minute)
+	if record, err := store.Begin(ctx, "k1", "fp", time.Minute); record != nil || err != nil {
+		t.Fatalf("Begin after lock expiry = %v, %v", record, err)
+	}
+}
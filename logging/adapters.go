@@ -0,0 +1,251 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+)
+
+// AdapterOption 设置 grpclog 和 go-redis 日志适配器选项
+type AdapterOption func(*adapterOptions)
+
+type adapterOptions struct {
+	verbosity int
+	infoLevel zapcore.Level
+	interval  time.Duration
+	burst     int
+}
+
+// WithVerbosity 设置 gRPC 的 verbosity，V(l) 在 l <= verbosity 时返回 true，默认 0
+func WithVerbosity(verbosity int) AdapterOption {
+	return func(o *adapterOptions) {
+		o.verbosity = verbosity
+	}
+}
+
+// WithInfoLevel 设置 info 日志写入的 level，gRPC 默认 Debug，go-redis 默认 Warn
+func WithInfoLevel(level zapcore.Level) AdapterOption {
+	return func(o *adapterOptions) {
+		o.infoLevel = level
+	}
+}
+
+// WithRateLimit 每个 interval 最多写入 burst 条日志，超出的丢弃并在下一条日志中报告丢弃数量，
+// burst <= 0 时不限制，默认每秒 100 条
+func WithRateLimit(interval time.Duration, burst int) AdapterOption {
+	return func(o *adapterOptions) {
+		o.interval = interval
+		o.burst = burst
+	}
+}
+
+func newAdapterOptions(infoLevel zapcore.Level, opts ...AdapterOption) *adapterOptions {
+	o := &adapterOptions{
+		infoLevel: infoLevel,
+		interval:  time.Second,
+		burst:     100,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// rateLimiter 固定窗口限流，记录被丢弃的数量
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu      sync.Mutex
+	window  time.Time
+	count   int
+	dropped int
+}
+
+// allow 返回是否写入，以及上次写入后丢弃的数量
+func (r *rateLimiter) allow() (bool, int) {
+	if r.burst <= 0 {
+		return true, 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.window) >= r.interval {
+		r.window, r.count = now, 0
+	}
+	if r.count >= r.burst {
+		r.dropped++
+		return false, 0
+	}
+	r.count++
+	dropped := r.dropped
+	r.dropped = 0
+	return true, dropped
+}
+
+// adapter 将第三方库的日志按 level 写入 Logger
+type adapter struct {
+	logger  Logger
+	opts    *adapterOptions
+	limiter *rateLimiter
+}
+
+func newAdapter(l Logger, o *adapterOptions) *adapter {
+	return &adapter{
+		logger:  l,
+		opts:    o,
+		limiter: &rateLimiter{interval: o.interval, burst: o.burst},
+	}
+}
+
+func (a *adapter) log(ctx context.Context, level zapcore.Level, msg string) {
+	// fatal 日志不限流
+	var dropped int
+	if level < zapcore.FatalLevel {
+		var ok bool
+		if ok, dropped = a.limiter.allow(); !ok {
+			return
+		}
+	}
+
+	var fields []zap.Field
+	if dropped > 0 {
+		fields = append(fields, zap.Int("dropped", dropped))
+	}
+	switch level {
+	case zapcore.DebugLevel:
+		a.logger.DebugCtx(ctx, msg, fields...)
+	case zapcore.InfoLevel:
+		a.logger.InfoCtx(ctx, msg, fields...)
+	case zapcore.WarnLevel:
+		a.logger.WarnCtx(ctx, msg, fields...)
+	case zapcore.ErrorLevel:
+		a.logger.ErrorCtx(ctx, msg, fields...)
+	default:
+		a.logger.FatalCtx(ctx, msg, fields...)
+	}
+}
+
+// grpcLogger 实现 grpclog.LoggerV2
+type grpcLogger struct {
+	*adapter
+}
+
+// NewGrpcLogger 返回写入 l.Named("grpc") 的 grpclog.LoggerV2，
+// 通常通过 InstallGrpcLogger 安装
+func NewGrpcLogger(l Logger, opts ...AdapterOption) grpclog.LoggerV2 {
+	return &grpcLogger{adapter: newAdapter(l.Named("grpc"), newAdapterOptions(zapcore.DebugLevel, opts...))}
+}
+
+func (g *grpcLogger) Info(args ...interface{}) {
+	g.log(context.Background(), g.opts.infoLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Infoln(args ...interface{}) {
+	g.log(context.Background(), g.opts.infoLevel, sprintln(args...))
+}
+
+func (g *grpcLogger) Infof(format string, args ...interface{}) {
+	g.log(context.Background(), g.opts.infoLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Warning(args ...interface{}) {
+	g.log(context.Background(), zapcore.WarnLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Warningln(args ...interface{}) {
+	g.log(context.Background(), zapcore.WarnLevel, sprintln(args...))
+}
+
+func (g *grpcLogger) Warningf(format string, args ...interface{}) {
+	g.log(context.Background(), zapcore.WarnLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Error(args ...interface{}) {
+	g.log(context.Background(), zapcore.ErrorLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Errorln(args ...interface{}) {
+	g.log(context.Background(), zapcore.ErrorLevel, sprintln(args...))
+}
+
+func (g *grpcLogger) Errorf(format string, args ...interface{}) {
+	g.log(context.Background(), zapcore.ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Fatal(args ...interface{}) {
+	g.log(context.Background(), zapcore.FatalLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Fatalln(args ...interface{}) {
+	g.log(context.Background(), zapcore.FatalLevel, sprintln(args...))
+}
+
+func (g *grpcLogger) Fatalf(format string, args ...interface{}) {
+	g.log(context.Background(), zapcore.FatalLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) V(l int) bool {
+	return l <= g.opts.verbosity
+}
+
+// sprintln 与 fmt.Sprintln 相同，但不带结尾换行
+func sprintln(args ...interface{}) string {
+	s := fmt.Sprintln(args...)
+	return s[:len(s)-1]
+}
+
+// RedisLogger 实现 go-redis 的 internal.Logging，通常通过 InstallRedisLogger 安装
+type RedisLogger struct {
+	*adapter
+}
+
+// NewRedisLogger 返回写入 l.Named("redis") 的 go-redis 日志适配器
+func NewRedisLogger(l Logger, opts ...AdapterOption) *RedisLogger {
+	return &RedisLogger{adapter: newAdapter(l.Named("redis"), newAdapterOptions(zapcore.WarnLevel, opts...))}
+}
+
+func (r *RedisLogger) Printf(ctx context.Context, format string, v ...interface{}) {
+	r.log(ctx, r.opts.infoLevel, fmt.Sprintf(format, v...))
+}
+
+var (
+	grpcLoggerOnce  sync.Once
+	redisLoggerOnce sync.Once
+)
+
+// InstallGrpcLogger 将 gRPC 内部日志写入 l。grpclog 是全局的且不是并发安全的，
+// 只能在 init 或 main 中、创建任何 gRPC server 和 client 之前调用
+func InstallGrpcLogger(l Logger, opts ...AdapterOption) {
+	grpclog.SetLoggerV2(NewGrpcLogger(l, opts...))
+}
+
+// InstallRedisLogger 将 go-redis 内部日志写入 l。go-redis 的 logger 是全局的，
+// 只能在 init 或 main 中、创建任何 redis client 之前调用
+func InstallRedisLogger(l Logger, opts ...AdapterOption) {
+	redis.SetLogger(NewRedisLogger(l, opts...))
+}
+
+// InstallGrpcLoggerOnce 与 InstallGrpcLogger 相同，但进程内只有第一次调用生效，
+// 供 server.WithInternalLogger 和 client.WithInternalLogger 在创建 server 和 client 前调用
+func InstallGrpcLoggerOnce(l Logger, opts ...AdapterOption) {
+	grpcLoggerOnce.Do(func() {
+		InstallGrpcLogger(l, opts...)
+	})
+}
+
+// InstallRedisLoggerOnce 与 InstallRedisLogger 相同，但进程内只有第一次调用生效，
+// 供 redis.WithInternalLogger 在创建 client 前调用
+func InstallRedisLoggerOnce(l Logger, opts ...AdapterOption) {
+	redisLoggerOnce.Do(func() {
+		InstallRedisLogger(l, opts...)
+	})
+}
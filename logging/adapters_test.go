@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/grpclog"
+)
+
+func TestGrpcLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	g := NewGrpcLogger(NewLogger(zap.New(core)), WithVerbosity(2), WithRateLimit(time.Hour, 3))
+
+	g.Infof("channel %d created", 1)
+	g.Warningln("transport", "closing")
+	g.Error("failed")
+	g.Error("dropped")
+	g.Error("dropped")
+
+	if !g.V(2) || g.V(3) {
+		t.Fatal("unexpected verbosity")
+	}
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Level != zapcore.DebugLevel || entries[0].Message != "channel 1 created" || entries[0].LoggerName != "grpc" {
+		t.Fatalf("unexpected entry %+v", entries[0])
+	}
+	if entries[1].Message != "transport closing" || entries[1].Level != zapcore.WarnLevel {
+		t.Fatalf("unexpected entry %+v", entries[1])
+	}
+}
+
+func TestRateLimitReportsDropped(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	r := NewRedisLogger(NewLogger(zap.New(core)), WithRateLimit(20*time.Millisecond, 1))
+
+	r.Printf(context.Background(), "pool: %s", "first")
+	r.Printf(context.Background(), "pool: %s", "dropped")
+	time.Sleep(30 * time.Millisecond)
+	r.Printf(context.Background(), "pool: %s", "second")
+
+	entries := logs.All()
+	if len(entries) != 2 || entries[0].Level != zapcore.WarnLevel || entries[0].LoggerName != "redis" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if entries[1].ContextMap()["dropped"] != int64(1) {
+		t.Fatalf("expected dropped count, got %v", entries[1].ContextMap())
+	}
+}
+
+func TestInstallGrpcLoggerOnce(t *testing.T) {
+	first, firstLogs := observer.New(zapcore.DebugLevel)
+	second, secondLogs := observer.New(zapcore.DebugLevel)
+
+	InstallGrpcLoggerOnce(NewLogger(zap.New(first)))
+	InstallGrpcLoggerOnce(NewLogger(zap.New(second)))
+	grpclog.Warning("installed")
+
+	if firstLogs.FilterMessage("installed").Len() != 1 || secondLogs.Len() != 0 {
+		t.Fatalf("first = %d, second = %d entries", firstLogs.Len(), secondLogs.Len())
+	}
+}
@@ -0,0 +1,90 @@
+// Package audit records who did what to which resource, with a hash chained
+// sink so that edits to the trail can be detected.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/duolacloud/micro/logging"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Outcome of an audited action
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+// Event is one audit record
+type Event struct {
+	Time     time.Time         `json:"time"`
+	Actor    string            `json:"actor"`
+	Action   string            `json:"action"`
+	Resource string            `json:"resource,omitempty"`
+	Outcome  Outcome           `json:"outcome"`
+	Reason   string            `json:"reason,omitempty"`
+	TraceID  string            `json:"trace_id,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Sink stores audit events
+type Sink interface {
+	Write(event Event) error
+}
+
+// Option set audit logger option
+type Option func(*options)
+
+type options struct {
+	logger logging.Logger
+}
+
+// WithLogger also writes every event to logger at info level
+func WithLogger(logger logging.Logger) Option {
+	return func(o *options) {
+		o.logger = logger.Named("audit")
+	}
+}
+
+// AuditLogger writes audit events to a sink
+type AuditLogger struct {
+	sink Sink
+	opts *options
+}
+
+// NewAuditLogger returns an AuditLogger writing to sink
+func NewAuditLogger(sink Sink, opts ...Option) *AuditLogger {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &AuditLogger{sink: sink, opts: o}
+}
+
+// Log fills the time and trace id from ctx when missing and writes event
+func (a *AuditLogger) Log(ctx context.Context, event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.TraceID == "" {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			event.TraceID = sc.TraceID().String()
+		}
+	}
+
+	if a.opts.logger != nil {
+		a.opts.logger.InfoCtx(ctx, "audit",
+			zap.String("actor", event.Actor),
+			zap.String("action", event.Action),
+			zap.String("resource", event.Resource),
+			zap.String("outcome", string(event.Outcome)),
+			zap.String("reason", event.Reason),
+		)
+	}
+	return a.sink.Write(event)
+}
@@ -0,0 +1,292 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type memorySink struct {
+	events []Event
+	err    error
+}
+
+func (s *memorySink) Write(event Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestChainVerify(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewChainSink(&buf)
+	a := NewAuditLogger(sink)
+	for _, action := range []string{"create", "update", "delete"} {
+		if err := a.Log(context.Background(), Event{Actor: "alice", Action: action, Resource: "doc/1", Outcome: OutcomeSuccess}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := Verify(bytes.NewReader(buf.Bytes()))
+	if err != nil || n != 3 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+
+	tampered := strings.Replace(buf.String(), `"actor":"alice","action":"update"`, `"actor":"mallory","action":"update"`, 1)
+	if _, err := Verify(strings.NewReader(tampered)); !errors.Is(err, ErrTampered) {
+		t.Fatalf("modified record: err = %v", err)
+	}
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	removed := lines[0] + lines[2]
+	if _, err := Verify(strings.NewReader(removed)); !errors.Is(err, ErrTampered) {
+		t.Fatalf("removed record: err = %v", err)
+	}
+}
+
+func TestOpenChainFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		sink, err := OpenChainFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Write(Event{Actor: "bob", Action: "login", Outcome: OutcomeSuccess}); err != nil {
+			t.Fatal(err)
+		}
+		sink.Close()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if n, err := Verify(f); err != nil || n != 2 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+}
+
+type getNameRequest struct{ name string }
+
+func (r *getNameRequest) GetName() string { return r.name }
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	sink := &memorySink{}
+	var writeErr error
+	interceptor := NewAuditLogger(sink).UnaryServerInterceptor(
+		WithMethods("/pkg.Books/Delete*"),
+		WithActorFunc(MetadataActor("x-user-id")),
+		WithErrorHandler(func(ctx context.Context, event Event, err error) { writeErr = err }),
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-user-id", "alice"))
+
+	call := func(method string, err error) error {
+		_, callErr := interceptor(ctx, &getNameRequest{name: "books/1"}, &grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", err })
+		return callErr
+	}
+
+	call("/pkg.Books/GetBook", nil)
+	call("/pkg.Books/DeleteBook", nil)
+	call("/pkg.Books/DeleteShelf", status.Error(codes.PermissionDenied, "no"))
+
+	if len(sink.events) != 2 {
+		t.Fatalf("events = %+v", sink.events)
+	}
+	got := sink.events[0]
+	if got.Actor != "alice" || got.Action != "/pkg.Books/DeleteBook" || got.Resource != "books/1" || got.Outcome != OutcomeSuccess {
+		t.Fatalf("event = %+v", got)
+	}
+	if sink.events[1].Outcome != OutcomeDenied || sink.events[1].Reason != "PermissionDenied: no" {
+		t.Fatalf("event = %+v", sink.events[1])
+	}
+
+	// the handler already ran, the caller gets its result
+	sink.err = errors.New("disk full")
+	if err := call("/pkg.Books/DeleteBook", nil); err != nil || writeErr != sink.err {
+		t.Fatalf("err = %v, write err = %v", err, writeErr)
+	}
+}
+
+func TestDefaultActorIgnoresMetadata(t *testing.T) {
+	sink := &memorySink{}
+	interceptor := NewAuditLogger(sink).UnaryServerInterceptor(WithMethods("/pkg.Books/*"))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-user-id", "admin"))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+
+	interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/pkg.Books/DeleteBook"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	if len(sink.events) != 1 || sink.events[0].Actor != "10.0.0.1:5000" {
+		t.Fatalf("events = %+v", sink.events)
+	}
+}
+
+func TestVerifyTailTruncation(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewChainSink(&buf)
+	for i := 0; i < 3; i++ {
+		sink.Write(Event{Actor: "alice", Action: "update", Outcome: OutcomeSuccess})
+	}
+
+	// dropping whole records from the end keeps a valid chain, only the
+	// count tells
+	lines := strings.SplitAfter(buf.String(), "\n")
+	n, err := Verify(strings.NewReader(lines[0] + lines[1]))
+	if err != nil || n != 2 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+}
+
+func TestOpenChainFileTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := OpenChainFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Write(Event{Actor: "bob", Action: "login", Outcome: OutcomeSuccess})
+	sink.Close()
+
+	// a crash in the middle of the second write
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"seq":2,"event":{"actor":"bo`)
+	f.Close()
+
+	f, _ = os.Open(path)
+	n, err := Verify(f)
+	f.Close()
+	if n != 1 || !errors.Is(err, ErrTornRecord) {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+
+	sink, err = OpenChainFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(Event{Actor: "bob", Action: "logout", Outcome: OutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	f, _ = os.Open(path)
+	defer f.Close()
+	if n, err := Verify(f); err != nil || n != 2 {
+		t.Fatalf("Verify after reopen = %d, %v", n, err)
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	sink := &memorySink{}
+	interceptor := NewAuditLogger(sink).StreamServerInterceptor(WithMethods("/pkg.Books/Export"))
+	ss := &testServerStream{ctx: peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1}})}
+
+	handler := func(srv interface{}, stream grpc.ServerStream) error { return status.Error(codes.Unavailable, "gone") }
+	interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/pkg.Books/List"}, handler)
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/pkg.Books/Export"}, handler)
+
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("err = %v", err)
+	}
+	if len(sink.events) != 1 {
+		t.Fatalf("events = %+v", sink.events)
+	}
+	if got := sink.events[0]; got.Action != "/pkg.Books/Export" || got.Actor != "10.0.0.2:1" || got.Outcome != OutcomeFailure {
+		t.Fatalf("event = %+v", got)
+	}
+}
+
+// registerAuditedService registers audittest.Books with an `audited` bool
+// method option set on Delete only
+func registerAuditedService(t *testing.T) protoreflect.ExtensionType {
+	t.Helper()
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("audittest/books.proto"),
+		Package:    proto.String("audittest"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Empty")},
+		},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("audited"),
+			Number:   proto.Int32(50000),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum(),
+			Extendee: proto.String(".google.protobuf.MethodOptions"),
+		}},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext := dynamicpb.NewExtensionType(fd.Extensions().Get(0))
+
+	deleteOptions := &descriptorpb.MethodOptions{}
+	proto.SetExtension(deleteOptions, ext, true)
+	file.Name = proto.String("audittest/books_service.proto")
+	file.Extension = nil
+	file.MessageType = nil
+	file.Dependency = []string{"audittest/books.proto"}
+	file.Service = []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("Books"),
+		Method: []*descriptorpb.MethodDescriptorProto{
+			{Name: proto.String("Get"), InputType: proto.String(".audittest.Empty"), OutputType: proto.String(".audittest.Empty")},
+			{Name: proto.String("Delete"), InputType: proto.String(".audittest.Empty"), OutputType: proto.String(".audittest.Empty"), Options: deleteOptions},
+		},
+	}}
+
+	if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	serviceFile, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(serviceFile); err != nil {
+		t.Fatal(err)
+	}
+	return ext
+}
+
+func TestWithProtoOption(t *testing.T) {
+	ext := registerAuditedService(t)
+	if !hasOption("/audittest.Books/Delete", ext) || hasOption("/audittest.Books/Get", ext) || hasOption("/audittest.Books/Missing", ext) {
+		t.Fatal("hasOption does not follow the method option")
+	}
+
+	sink := &memorySink{}
+	interceptor := NewAuditLogger(sink).UnaryServerInterceptor(WithProtoOption(ext))
+	for _, method := range []string{"/audittest.Books/Get", "/audittest.Books/Delete", "/audittest.Books/Delete"} {
+		interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	}
+	if len(sink.events) != 2 || sink.events[0].Action != "/audittest.Books/Delete" {
+		t.Fatalf("events = %+v", sink.events)
+	}
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	// ErrTampered is returned by Verify when the chain is broken
+	ErrTampered = errors.New("audit: chain broken")
+	// ErrTornRecord is returned by Verify when the last line is incomplete,
+	// e.g. the process died while writing it
+	ErrTornRecord = errors.New("audit: incomplete last record")
+)
+
+// record is one line of a chained audit log, Hash is
+// sha256(Prev + JSON(Event)) so changing, removing or reordering lines
+// breaks the chain
+type record struct {
+	Seq   uint64          `json:"seq"`
+	Event json.RawMessage `json:"event"`
+	Prev  string          `json:"prev"`
+	Hash  string          `json:"hash"`
+}
+
+func chainHash(prev string, event []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prev))
+	h.Write(event)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChainSink writes events as hash chained JSON lines
+type ChainSink struct {
+	mu   sync.Mutex
+	w    io.Writer
+	seq  uint64
+	prev string
+	file *os.File
+}
+
+// NewChainSink starts a new chain on w
+func NewChainSink(w io.Writer) *ChainSink {
+	return &ChainSink{w: w}
+}
+
+// OpenChainFile appends to the chain in path, the existing file is verified
+// and the chain continues from its last record. An incomplete last line left
+// by a crash is cut off, a broken chain is an error.
+func OpenChainFile(path string) (*ChainSink, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	last, size, err := verify(f)
+	if errors.Is(err, ErrTornRecord) {
+		err = f.Truncate(size)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &ChainSink{w: f, seq: last.Seq, prev: last.Hash, file: f}, nil
+}
+
+// Write appends event to the chain
+func (s *ChainSink) Write(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := record{Seq: s.seq + 1, Event: data, Prev: s.prev}
+	r.Hash = chainHash(r.Prev, data)
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.seq, s.prev = r.Seq, r.Hash
+	return nil
+}
+
+// Close closes the file opened by OpenChainFile
+func (s *ChainSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// Verify checks the chain read from r and returns the number of valid
+// records. Records removed from the end of the file leave a valid chain, so
+// compare the count or the last hash with a copy kept elsewhere to detect
+// truncation.
+func Verify(r io.Reader) (int, error) {
+	last, _, err := verify(r)
+	return int(last.Seq), err
+}
+
+// verify returns the last valid record and the size of the valid prefix
+func verify(r io.Reader) (record, int64, error) {
+	var (
+		last record
+		size int64
+	)
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) == 0 {
+				return last, size, nil
+			}
+			// every record is written with its newline in one write
+			return last, size, fmt.Errorf("%w: line %d", ErrTornRecord, line)
+		}
+		if err != nil {
+			return last, size, err
+		}
+
+		var rec record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return last, size, fmt.Errorf("%w: line %d: %v", ErrTampered, line, err)
+		}
+		if rec.Seq != last.Seq+1 {
+			return last, size, fmt.Errorf("%w: line %d: sequence %d after %d", ErrTampered, line, rec.Seq, last.Seq)
+		}
+		if rec.Prev != last.Hash {
+			return last, size, fmt.Errorf("%w: line %d: previous hash mismatch", ErrTampered, line)
+		}
+		if chainHash(rec.Prev, rec.Event) != rec.Hash {
+			return last, size, fmt.Errorf("%w: line %d: hash mismatch", ErrTampered, line)
+		}
+		last = rec
+		size += int64(len(data))
+	}
+}
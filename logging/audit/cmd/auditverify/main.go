@@ -0,0 +1,45 @@
+// Command auditverify checks the hash chain of audit log files written by
+// audit.ChainSink, it exits 1 when a file was tampered with.
+//
+//	auditverify audit.log [more.log ...]
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/duolacloud/micro/logging/audit"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: auditverify FILE...")
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, path := range os.Args[1:] {
+		if err := verify(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func verify(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := audit.Verify(f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: ok, %d records\n", path, n)
+	return nil
+}
@@ -0,0 +1,250 @@
+package audit
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/duolacloud/micro/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ActorFunc returns the caller of an rpc
+type ActorFunc func(ctx context.Context) string
+
+// ResourceFunc returns the resource an rpc acts on, req is nil for streams
+type ResourceFunc func(ctx context.Context, method string, req interface{}) string
+
+// InterceptorOption set interceptor option
+type InterceptorOption func(*interceptorOptions)
+
+type interceptorOptions struct {
+	methods   []string
+	extension protoreflect.ExtensionType
+	actor     ActorFunc
+	resource  ResourceFunc
+	onError   func(ctx context.Context, event Event, err error)
+}
+
+func defaultInterceptorOptions() *interceptorOptions {
+	return &interceptorOptions{
+		actor:    PeerActor,
+		resource: RequestResource,
+	}
+}
+
+// WithMethods audits methods matching any of the path.Match patterns, e.g.
+// "/pkg.Service/Delete*" or "/pkg.Admin/*"
+func WithMethods(patterns ...string) InterceptorOption {
+	return func(o *interceptorOptions) {
+		o.methods = append(o.methods, patterns...)
+	}
+}
+
+// WithProtoOption audits methods whose MethodOptions set the bool extension
+// ext to true, e.g. `option (acme.audit) = true;`
+func WithProtoOption(ext protoreflect.ExtensionType) InterceptorOption {
+	return func(o *interceptorOptions) {
+		o.extension = ext
+	}
+}
+
+// WithActorFunc set how the actor is found
+func WithActorFunc(fn ActorFunc) InterceptorOption {
+	return func(o *interceptorOptions) {
+		o.actor = fn
+	}
+}
+
+// WithErrorHandler set what happens when an event cannot be written, by
+// default the error is logged
+func WithErrorHandler(fn func(ctx context.Context, event Event, err error)) InterceptorOption {
+	return func(o *interceptorOptions) {
+		o.onError = fn
+	}
+}
+
+// WithResourceFunc set how the resource is found
+func WithResourceFunc(fn ResourceFunc) InterceptorOption {
+	return func(o *interceptorOptions) {
+		o.resource = fn
+	}
+}
+
+// PeerActor is the default actor, the subject of a verified client
+// certificate or else the peer address. Services authenticating callers
+// some other way should pass their own ActorFunc reading that identity.
+func PeerActor(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+		return info.State.VerifiedChains[0][0].Subject.String()
+	}
+	if p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// MetadataActor reads the actor from the first present incoming metadata
+// key, falling back to PeerActor. Clients set metadata freely, so only use
+// it when a trusted proxy or an earlier interceptor sets the key.
+func MetadataActor(keys ...string) ActorFunc {
+	return func(ctx context.Context) string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, key := range keys {
+			if v := md.Get(key); len(v) > 0 && v[0] != "" {
+				return v[0]
+			}
+		}
+		return PeerActor(ctx)
+	}
+}
+
+// RequestResource uses the request's name or id field when it has one
+func RequestResource(ctx context.Context, method string, req interface{}) string {
+	switch r := req.(type) {
+	case interface{ GetName() string }:
+		return r.GetName()
+	case interface{ GetId() string }:
+		return r.GetId()
+	}
+	return ""
+}
+
+type matcher struct {
+	opts *interceptorOptions
+	// caches the proto option lookups, methods are a fixed set
+	marked sync.Map
+}
+
+func newMatcher(o *interceptorOptions) *matcher {
+	return &matcher{opts: o}
+}
+
+func (m *matcher) audited(method string) bool {
+	for _, pattern := range m.opts.methods {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	if m.opts.extension == nil {
+		return false
+	}
+
+	if marked, ok := m.marked.Load(method); ok {
+		return marked.(bool)
+	}
+	marked := hasOption(method, m.opts.extension)
+	m.marked.Store(method, marked)
+	return marked
+}
+
+// hasOption looks up "/pkg.Service/Method" in the global registry
+func hasOption(method string, ext protoreflect.ExtensionType) bool {
+	name := strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", 1)
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return false
+	}
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok || md.Options() == nil {
+		return false
+	}
+	v, ok := proto.GetExtension(md.Options(), ext).(bool)
+	return ok && v
+}
+
+func (a *AuditLogger) event(ctx context.Context, o *interceptorOptions, method string, req interface{}, err error) Event {
+	event := Event{
+		Actor:    o.actor(ctx),
+		Action:   method,
+		Resource: o.resource(ctx, method, req),
+		Outcome:  OutcomeSuccess,
+	}
+	if err != nil {
+		s := status.Convert(err)
+		event.Outcome = OutcomeFailure
+		if s.Code() == codes.PermissionDenied || s.Code() == codes.Unauthenticated {
+			event.Outcome = OutcomeDenied
+		}
+		event.Reason = s.Code().String() + ": " + s.Message()
+	}
+	return event
+}
+
+// UnaryServerInterceptor writes an event for each audited unary rpc after the
+// handler ran. The handler's changes are already committed then, so a
+// failure to write the event goes to the error handler and the caller still
+// gets the real response.
+func (a *AuditLogger) UnaryServerInterceptor(opts ...InterceptorOption) grpc.UnaryServerInterceptor {
+	o := a.interceptorOptions(opts)
+	m := newMatcher(o)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !m.audited(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		resp, err := handler(ctx, req)
+		a.write(ctx, o, a.event(ctx, o, info.FullMethod, req, err))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor writes an event for each audited stream when it
+// ends, write failures are handled as in UnaryServerInterceptor
+func (a *AuditLogger) StreamServerInterceptor(opts ...InterceptorOption) grpc.StreamServerInterceptor {
+	o := a.interceptorOptions(opts)
+	m := newMatcher(o)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !m.audited(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		err := handler(srv, ss)
+		ctx := ss.Context()
+		a.write(ctx, o, a.event(ctx, o, info.FullMethod, nil, err))
+		return err
+	}
+}
+
+func (a *AuditLogger) interceptorOptions(opts []InterceptorOption) *interceptorOptions {
+	o := defaultInterceptorOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.onError == nil {
+		logger := a.opts.logger
+		if logger == nil {
+			logger = logging.NewLogger(zap.L()).Named("audit")
+		}
+		o.onError = func(ctx context.Context, event Event, err error) {
+			logger.ErrorCtx(ctx, "audit event not written",
+				zap.String("actor", event.Actor),
+				zap.String("action", event.Action),
+				zap.Error(err),
+			)
+		}
+	}
+	return o
+}
+
+func (a *AuditLogger) write(ctx context.Context, o *interceptorOptions, event Event) {
+	if err := a.Log(ctx, event); err != nil {
+		o.onError(ctx, event, err)
+	}
+}
@@ -0,0 +1,322 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 描述 logger 的输出，可以从 YAML 或环境变量加载
+type Config struct {
+	// Level 默认 level，运行时可通过 Levels 修改，默认 info
+	Level string `yaml:"level"`
+	// Encoding 为 json 或 console，默认 json
+	Encoding string `yaml:"encoding"`
+
+	// Service、Version、Env 以及 Fields 作为静态字段写入每条日志
+	Service string            `yaml:"service"`
+	Version string            `yaml:"version"`
+	Env     string            `yaml:"env"`
+	Fields  map[string]string `yaml:"fields"`
+
+	// Sinks 为空时输出到 stdout
+	Sinks []SinkConfig `yaml:"sinks"`
+
+	// Async 为 true 时缓冲写入，关闭时刷新
+	Async         bool          `yaml:"async"`
+	BufferSize    int           `yaml:"buffer_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+
+	// DisableCaller 不记录调用位置，StacktraceLevel 及以上记录堆栈，默认 error
+	DisableCaller   bool   `yaml:"disable_caller"`
+	StacktraceLevel string `yaml:"stacktrace_level"`
+}
+
+// SinkConfig 描述一个输出
+type SinkConfig struct {
+	// Type 为 stdout、stderr 或 file
+	Type string `yaml:"type"`
+	// Level 为该输出的最低 level，为空时不额外过滤
+	Level string `yaml:"level"`
+	// Encoding 覆盖 Config.Encoding
+	Encoding string `yaml:"encoding"`
+
+	// 以下为 file 的配置，按大小滚动，MaxAge 和 MaxBackups 为 0 时不清理
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+	MaxBackups int    `yaml:"max_backups"`
+	Compress   bool   `yaml:"compress"`
+	LocalTime  bool   `yaml:"local_time"`
+}
+
+// LoadConfig 从 YAML 文件加载配置
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig 解析 YAML 配置，${VAR} 会被替换为环境变量
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return Config{}, fmt.Errorf("logging: parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ConfigFromEnv 从 prefix 开头的环境变量加载配置，例如 prefix 为 "LOG" 时读取
+// LOG_LEVEL、LOG_ENCODING、LOG_SERVICE、LOG_VERSION、LOG_ENV、LOG_ASYNC、
+// LOG_OUTPUT（逗号分隔的 stdout、stderr 或文件路径）以及
+// LOG_FILE_MAX_SIZE_MB、LOG_FILE_MAX_AGE_DAYS、LOG_FILE_MAX_BACKUPS、LOG_FILE_COMPRESS
+func ConfigFromEnv(prefix string) (Config, error) {
+	env := func(key string) string {
+		return os.Getenv(prefix + "_" + key)
+	}
+
+	cfg := Config{
+		Level:    env("LEVEL"),
+		Encoding: env("ENCODING"),
+		Service:  env("SERVICE"),
+		Version:  env("VERSION"),
+		Env:      env("ENV"),
+	}
+
+	var err error
+	if v := env("ASYNC"); v != "" {
+		if cfg.Async, err = cast.ToBoolE(v); err != nil {
+			return Config{}, fmt.Errorf("logging: %s_ASYNC: %w", prefix, err)
+		}
+	}
+
+	var file SinkConfig
+	for key, dst := range map[string]*int{
+		"FILE_MAX_SIZE_MB":  &file.MaxSizeMB,
+		"FILE_MAX_AGE_DAYS": &file.MaxAgeDays,
+		"FILE_MAX_BACKUPS":  &file.MaxBackups,
+	} {
+		if v := env(key); v != "" {
+			if *dst, err = cast.ToIntE(v); err != nil {
+				return Config{}, fmt.Errorf("logging: %s_%s: %w", prefix, key, err)
+			}
+		}
+	}
+	if v := env("FILE_COMPRESS"); v != "" {
+		if file.Compress, err = cast.ToBoolE(v); err != nil {
+			return Config{}, fmt.Errorf("logging: %s_FILE_COMPRESS: %w", prefix, err)
+		}
+	}
+
+	for _, output := range strings.Split(env("OUTPUT"), ",") {
+		switch output = strings.TrimSpace(output); output {
+		case "":
+		case "stdout", "stderr":
+			cfg.Sinks = append(cfg.Sinks, SinkConfig{Type: output})
+		default:
+			sink := file
+			sink.Type, sink.Path = "file", output
+			cfg.Sinks = append(cfg.Sinks, sink)
+		}
+	}
+	return cfg, nil
+}
+
+// New 按配置创建 Logger，返回的 close 刷新缓冲并关闭文件，退出前调用。
+// opts 可以追加其他选项，未指定 WithLevels 时使用以 cfg.Level 为默认值的 Levels
+func New(cfg Config, opts ...Option) (Logger, func() error, error) {
+	level, err := parseLevel(cfg.Level, zapcore.InfoLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	stackLevel, err := parseLevel(cfg.StacktraceLevel, zapcore.ErrorLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = []SinkConfig{{Type: "stdout"}}
+	}
+
+	var cores []zapcore.Core
+	var closers []func() error
+	closeAll := func() error {
+		var errs error
+		for _, c := range closers {
+			errs = multierr.Append(errs, c())
+		}
+		return errs
+	}
+
+	for i, sink := range sinks {
+		core, closer, err := newSinkCore(cfg, sink)
+		if err != nil {
+			_ = closeAll()
+			return nil, nil, fmt.Errorf("logging: sink %d: %w", i, err)
+		}
+		cores = append(cores, core)
+		closers = append(closers, closer)
+	}
+
+	zapOpts := []zap.Option{zap.AddStacktrace(stackLevel), zap.Fields(staticFields(cfg)...)}
+	if !cfg.DisableCaller {
+		// 跳过 Logger 的包装方法，caller 指向调用方
+		zapOpts = append(zapOpts, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+	zapLogger := zap.New(zapcore.NewTee(cores...), zapOpts...)
+
+	o := defaultOptions()
+	o.apply(opts...)
+	if o.levels == nil {
+		opts = append([]Option{WithLevels(NewLevels(level))}, opts...)
+	}
+
+	l := NewLogger(zapLogger, opts...).(*logger)
+	closeFn := func() error {
+		// 经过完整的 core 链 Sync，先写出去重等待中的汇总，再关闭输出
+		return multierr.Append(l.base.Sync(), closeAll())
+	}
+	return l, closeFn, nil
+}
+
+func newSinkCore(cfg Config, sink SinkConfig) (zapcore.Core, func() error, error) {
+	encoding := sink.Encoding
+	if encoding == "" {
+		encoding = cfg.Encoding
+	}
+	// 只有终端输出着色，文件中不写入颜色转义序列
+	encoder, err := newEncoder(encoding, sink.Type != "file")
+	if err != nil {
+		return nil, nil, err
+	}
+	// 默认不过滤，由 Levels 控制
+	level, err := parseLevel(sink.Level, zapcore.DebugLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ws zapcore.WriteSyncer
+	closer := func() error { return nil }
+	switch sink.Type {
+	case "", "stdout":
+		ws = zapcore.Lock(os.Stdout)
+	case "stderr":
+		ws = zapcore.Lock(os.Stderr)
+	case "file":
+		if sink.Path == "" {
+			return nil, nil, fmt.Errorf("file sink without path")
+		}
+		file := &lumberjack.Logger{
+			Filename:   sink.Path,
+			MaxSize:    sink.MaxSizeMB,
+			MaxAge:     sink.MaxAgeDays,
+			MaxBackups: sink.MaxBackups,
+			Compress:   sink.Compress,
+			LocalTime:  sink.LocalTime,
+		}
+		ws = zapcore.AddSync(file)
+		closer = file.Close
+	default:
+		return nil, nil, fmt.Errorf("unknown sink type %q", sink.Type)
+	}
+
+	if cfg.Async {
+		buffered := &zapcore.BufferedWriteSyncer{
+			WS:            ws,
+			Size:          cfg.BufferSize,
+			FlushInterval: cfg.FlushInterval,
+		}
+		ws = buffered
+		fileCloser := closer
+		closer = func() error {
+			return multierr.Append(buffered.Stop(), fileCloser())
+		}
+	}
+
+	return &sinkCore{Core: zapcore.NewCore(encoder, ws, level), level: level}, closer, nil
+}
+
+// sinkCore 让输出的 level 在 Levels 强制写入时同样生效
+type sinkCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sinkCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.level.Enabled(ent.Level) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func newEncoder(encoding string, color bool) (zapcore.Encoder, error) {
+	switch encoding {
+	case "", "json":
+		ec := zap.NewProductionEncoderConfig()
+		ec.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewJSONEncoder(ec), nil
+	case "console":
+		ec := zap.NewDevelopmentEncoderConfig()
+		ec.EncodeLevel = zapcore.CapitalLevelEncoder
+		if color {
+			ec.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(ec), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+func parseLevel(text string, def zapcore.Level) (zapcore.Level, error) {
+	if text == "" {
+		return def, nil
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(text)); err != nil {
+		return def, fmt.Errorf("logging: %w", err)
+	}
+	return level, nil
+}
+
+func staticFields(cfg Config) []zap.Field {
+	var fields []zap.Field
+	for _, f := range []struct{ key, value string }{
+		{"service", cfg.Service}, {"version", cfg.Version}, {"env", cfg.Env},
+	} {
+		if f.value != "" {
+			fields = append(fields, zap.String(f.key, f.value))
+		}
+	}
+	keys := make([]string, 0, len(cfg.Fields))
+	for key := range cfg.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, zap.String(key, cfg.Fields[key]))
+	}
+	return fields
+}
@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFromYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	t.Setenv("TEST_LOG_PATH", path)
+
+	cfg, err := ParseConfig([]byte(`
+level: debug
+service: orders
+version: 1.2.3
+env: test
+fields:
+  region: eu
+async: true
+sinks:
+  - type: file
+    path: ${TEST_LOG_PATH}
+    level: warn
+    max_size_mb: 10
+    max_backups: 3
+    compress: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, closeFn, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("skipped by the sink level")
+	l.Named("db").Warn("slow query")
+	if err := closeFn(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %q", data)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"msg": "slow query", "logger": "db", "service": "orders", "version": "1.2.3", "env": "test", "region": "eu",
+	} {
+		if record[key] != want {
+			t.Fatalf("%s = %v, want %s", key, record[key], want)
+		}
+	}
+	if caller, _ := record["caller"].(string); !strings.HasPrefix(caller, "logging/config_test.go") {
+		t.Fatalf("caller should point at the call site, got %v", record["caller"])
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "warn")
+	t.Setenv("APP_LOG_OUTPUT", "stdout, /var/log/app.log")
+	t.Setenv("APP_LOG_FILE_MAX_SIZE_MB", "50")
+	t.Setenv("APP_LOG_ASYNC", "true")
+
+	cfg, err := ConfigFromEnv("APP_LOG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "warn" || !cfg.Async || len(cfg.Sinks) != 2 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if file := cfg.Sinks[1]; file.Type != "file" || file.Path != "/var/log/app.log" || file.MaxSizeMB != 50 {
+		t.Fatalf("unexpected file sink %+v", file)
+	}
+
+	t.Setenv("APP_LOG_ASYNC", "maybe")
+	if _, err := ConfigFromEnv("APP_LOG"); err == nil {
+		t.Fatal("expected error for a bad bool")
+	}
+}
+
+func TestCloseFlushesDedup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, closeFn, err := New(Config{Sinks: []SinkConfig{{Type: "file", Path: path}}}, WithDedup(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		l.Warn("retrying")
+	}
+	if err := closeFn(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "retrying (repeated 2 times)") {
+		t.Fatalf("dedup summary not flushed on close: %s", data)
+	}
+}
+
+func TestConsoleFileSinkWithoutColor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, closeFn, err := New(Config{Encoding: "console", Sinks: []SinkConfig{{Type: "file", Path: path}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Warn("disk almost full")
+	if err := closeFn(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "\x1b[") || !strings.Contains(string(data), "\tWARN\t") {
+		t.Fatalf("unexpected console line %q", data)
+	}
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// ContextExtractor 从 context 中提取日志字段，*Ctx 方法调用时执行
+type ContextExtractor func(ctx context.Context) []zap.Field
+
+// fieldSet 是 WithFields 附加到 context 的字段，parent 指向上一次附加的字段
+type fieldSet struct {
+	fields []zap.Field
+	parent *fieldSet
+}
+
+type fieldsKey struct{}
+
+type loggerKey struct{}
+
+// WithFields 将 fields 附加到 ctx，之后所有 *Ctx 调用和 FromContext 返回的 logger 都会带上它们
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	parent, _ := ctx.Value(fieldsKey{}).(*fieldSet)
+	return context.WithValue(ctx, fieldsKey{}, &fieldSet{fields: fields, parent: parent})
+}
+
+// NewContext 将 logger 保存到 ctx 中
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext 返回 NewContext 保存的 logger 并绑定 WithFields 附加的字段，
+// 没有保存时使用 zap.L()
+func FromContext(ctx context.Context) Logger {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		l = NewLogger(zap.L())
+	}
+	set, _ := ctx.Value(fieldsKey{}).(*fieldSet)
+	if set == nil {
+		return l
+	}
+	if impl, ok := l.(*logger); ok {
+		return impl.bind(set)
+	}
+	return l.With(collectFields(set, nil)...)
+}
+
+// collectFields 按附加顺序返回 set 中不属于 bound 的字段
+func collectFields(set, bound *fieldSet) []zap.Field {
+	seen := map[*fieldSet]bool{}
+	for s := bound; s != nil; s = s.parent {
+		seen[s] = true
+	}
+	var chain []*fieldSet
+	for s := set; s != nil && !seen[s]; s = s.parent {
+		chain = append(chain, s)
+	}
+	var fields []zap.Field
+	for i := len(chain) - 1; i >= 0; i-- {
+		fields = append(fields, chain[i].fields...)
+	}
+	return fields
+}
+
+// TraceIDExtractor 提取 trace_id，默认启用
+func TraceIDExtractor(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{zap.String("trace_id", sc.TraceID().String())}
+}
+
+// SpanIDExtractor 提取 span_id
+func SpanIDExtractor(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{zap.String("span_id", sc.SpanID().String())}
+}
+
+// TraceFlagsExtractor 提取 trace_flags 和是否采样
+func TraceFlagsExtractor(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_flags", sc.TraceFlags().String()),
+		zap.Bool("trace_sampled", sc.IsSampled()),
+	}
+}
+
+// BaggageExtractor 提取 OTel baggage 成员，字段名为 "baggage.<key>"，
+// keys 为空时提取所有成员
+func BaggageExtractor(keys ...string) ContextExtractor {
+	return func(ctx context.Context) []zap.Field {
+		bag := baggage.FromContext(ctx)
+		if bag.Len() == 0 {
+			return nil
+		}
+		var fields []zap.Field
+		if len(keys) == 0 {
+			for _, m := range bag.Members() {
+				fields = append(fields, zap.String("baggage."+m.Key(), m.Value()))
+			}
+			return fields
+		}
+		for _, key := range keys {
+			if m := bag.Member(key); m.Key() != "" {
+				fields = append(fields, zap.String("baggage."+key, m.Value()))
+			}
+		}
+		return fields
+	}
+}
+
+// GrpcExtractor 提取 gRPC 方法和对端地址
+func GrpcExtractor(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if method, ok := grpc.Method(ctx); ok {
+		fields = append(fields, zap.String("grpc.method", method))
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer.address", p.Addr.String()))
+	}
+	return fields
+}
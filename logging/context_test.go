@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContextFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core), WithContextExtractors(SpanIDExtractor, BaggageExtractor()))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	member, _ := baggage.NewMember("tenant", "acme")
+	bag, _ := baggage.New(member)
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+	ctx = NewContext(ctx, l)
+	ctx = WithFields(ctx, zap.String("request_id", "req-1"))
+	ctx = WithFields(ctx, zap.String("user", "bob"))
+
+	l.InfoCtx(ctx, "direct")
+	entry := logs.All()[0].ContextMap()
+	for key, want := range map[string]string{
+		"request_id":     "req-1",
+		"user":           "bob",
+		"trace_id":       sc.TraceID().String(),
+		"span_id":        sc.SpanID().String(),
+		"baggage.tenant": "acme",
+	} {
+		if entry[key] != want {
+			t.Fatalf("%s = %v, want %s", key, entry[key], want)
+		}
+	}
+
+	// fields bound by FromContext are not repeated by *Ctx calls
+	FromContext(ctx).InfoCtx(ctx, "bound")
+	if got := len(logs.All()[1].Context); got != len(logs.All()[0].Context) {
+		t.Fatalf("expected %d fields, got %d", len(logs.All()[0].Context), got)
+	}
+
+	FromContext(ctx).Info("plain")
+	if logs.All()[2].ContextMap()["user"] != "bob" {
+		t.Fatal("FromContext should bind context fields")
+	}
+}
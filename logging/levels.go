@@ -0,0 +1,221 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels 按 logger 名称保存 zap.AtomicLevel，支持 glob 覆盖，运行时可修改
+type Levels struct {
+	mu  sync.Mutex
+	def zap.AtomicLevel
+	// min 是默认 level 和所有覆盖中最低的，floorCore 用它判断是否可能放行
+	min       zap.AtomicLevel
+	overrides map[string]zapcore.Level
+	levels    map[string]zap.AtomicLevel
+}
+
+// NewLevels 创建 registry，未匹配任何覆盖的 logger 使用 level
+func NewLevels(level zapcore.Level) *Levels {
+	return &Levels{
+		def:       zap.NewAtomicLevelAt(level),
+		min:       zap.NewAtomicLevelAt(level),
+		overrides: map[string]zapcore.Level{},
+		levels:    map[string]zap.AtomicLevel{},
+	}
+}
+
+// Level 返回 name 对应的 level，不存在时创建
+func (l *Levels) Level(name string) zap.AtomicLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	level, ok := l.levels[name]
+	if !ok {
+		level = zap.NewAtomicLevelAt(l.resolve(name))
+		l.levels[name] = level
+	}
+	return level
+}
+
+// SetDefault 修改默认 level，没有覆盖的 logger 立即生效
+func (l *Levels) SetDefault(level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.def.SetLevel(level)
+	l.refresh()
+}
+
+// SetLevel 为匹配 pattern 的 logger 设置 level，pattern 为 path.Match 语法，
+// 例如 "redis" 或 "redis*"
+func (l *Levels) SetLevel(pattern string, level zapcore.Level) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("logging: bad pattern %q: %w", pattern, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides[pattern] = level
+	l.refresh()
+	return nil
+}
+
+// Unset 删除 pattern 的覆盖
+func (l *Levels) Unset(pattern string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.overrides, pattern)
+	l.refresh()
+}
+
+// refresh 重新计算所有已创建 logger 的 level
+func (l *Levels) refresh() {
+	for name, level := range l.levels {
+		level.SetLevel(l.resolve(name))
+	}
+	min := l.def.Level()
+	for _, level := range l.overrides {
+		if level < min {
+			min = level
+		}
+	}
+	l.min.SetLevel(min)
+}
+
+// resolve 精确匹配优先，其次是最长的 glob
+func (l *Levels) resolve(name string) zapcore.Level {
+	if level, ok := l.overrides[name]; ok {
+		return level
+	}
+	best, level := -1, l.def.Level()
+	for pattern, lvl := range l.overrides {
+		if ok, _ := path.Match(pattern, name); ok && len(pattern) > best {
+			best, level = len(pattern), lvl
+		}
+	}
+	return level
+}
+
+type levelsPayload struct {
+	Default   string            `json:"default"`
+	Overrides map[string]string `json:"overrides"`
+	Loggers   map[string]string `json:"loggers"`
+}
+
+type levelRequest struct {
+	Pattern string `json:"pattern"`
+	Level   string `json:"level"`
+}
+
+// ServeHTTP GET 返回默认 level、覆盖和所有 logger 的当前 level；
+// PUT {"pattern":"redis*","level":"debug"} 设置覆盖，pattern 为空时修改默认 level；
+// DELETE ?pattern=redis* 删除覆盖
+func (l *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Pattern == "" {
+			l.SetDefault(level)
+		} else if err := l.SetLevel(req.Pattern, level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		l.Unset(r.URL.Query().Get("pattern"))
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(l.snapshot())
+}
+
+func (l *Levels) snapshot() levelsPayload {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := levelsPayload{
+		Default:   l.def.Level().String(),
+		Overrides: make(map[string]string, len(l.overrides)),
+		Loggers:   make(map[string]string, len(l.levels)),
+	}
+	for pattern, level := range l.overrides {
+		p.Overrides[pattern] = level.String()
+	}
+	for name, level := range l.levels {
+		p.Loggers[name] = level.Level().String()
+	}
+	return p
+}
+
+// levelCore 用 name 对应的 level 过滤日志，level 低于底层 core 时由最内层的
+// floorCore 放行，这样可以只给某个组件打开 debug
+type levelCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// floorCore 包装用户传入的 core，放行 Levels 中低于它的 level 的日志。
+// 它位于采样、去重、脱敏和 OTel 等 core 之内，强制写入的日志仍经过它们的过滤
+type floorCore struct {
+	zapcore.Core
+	levels *Levels
+	// prefix 是用户 logger 自带的名称，Levels 中的名称不包含它
+	prefix string
+}
+
+func (c *floorCore) Enabled(level zapcore.Level) bool {
+	return c.Core.Enabled(level) || c.levels.min.Enabled(level)
+}
+
+func (c *floorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &floorCore{Core: c.Core.With(fields), levels: c.levels, prefix: c.prefix}
+}
+
+func (c *floorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(ent.LoggerName, c.prefix), ".")
+	if c.levels.Level(name).Enabled(ent.Level) {
+		return ce.AddCore(ent, c.Core)
+	}
+	return ce
+}
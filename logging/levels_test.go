@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNamedLevels(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	levels := NewLevels(zapcore.InfoLevel)
+	root := NewLogger(zap.New(core), WithLevels(levels))
+	redis := root.Named("redis")
+	pool := redis.Named("pool")
+	grpc := root.Named("grpc")
+
+	redis.Debug("dropped")
+	if logs.Len() != 0 {
+		t.Fatal("debug should be disabled by default")
+	}
+
+	if err := levels.SetLevel("redis*", zapcore.DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	redis.Debug("redis debug")
+	pool.Debug("pool debug")
+	grpc.Debug("grpc debug")
+	if logs.Len() != 2 {
+		t.Fatalf("expected 2 debug entries, got %d", logs.Len())
+	}
+	if logs.All()[1].LoggerName != "redis.pool" {
+		t.Fatalf("unexpected logger name %q", logs.All()[1].LoggerName)
+	}
+
+	// exact names win over globs
+	levels.SetLevel("redis.pool", zapcore.ErrorLevel)
+	pool.Warn("dropped")
+	if logs.Len() != 2 {
+		t.Fatal("pool warn should be filtered")
+	}
+
+	srv := httptest.NewServer(levels)
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(`{"pattern":"grpc","level":"debug"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	grpc.Sugar().Desugar().Debug("grpc debug")
+	if logs.Len() != 3 {
+		t.Fatalf("expected grpc debug after PUT, got %d entries", logs.Len())
+	}
+}
+
+func TestForcedLevelKeepsInnerFilters(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	levels := NewLevels(zapcore.InfoLevel)
+	levels.SetLevel("redis", zapcore.DebugLevel)
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "op")
+
+	l := NewLogger(zap.New(core),
+		WithLevels(levels),
+		WithSampling(zapcore.DebugLevel, time.Minute, 2, 0),
+		WithSpanEvents(zapcore.InfoLevel),
+	).Named("redis")
+
+	// sampling still applies to the forced debug entries
+	for i := 0; i < 5; i++ {
+		l.Debug("forced")
+	}
+	if got := logs.FilterMessage("forced").Len(); got != 2 {
+		t.Fatalf("expected 2 sampled debug entries, got %d", got)
+	}
+
+	// and the span event core keeps its own info level
+	l.DebugCtx(ctx, "forced in span")
+	span.End()
+	if logs.FilterMessage("forced in span").Len() != 1 {
+		t.Fatal("forced debug entry in a sampled trace was dropped")
+	}
+	if events := recorder.Ended()[0].Events(); len(events) != 0 {
+		t.Fatalf("debug entries leaked into span events: %v", events)
+	}
+}
@@ -3,8 +3,8 @@ package logging
 import (
 	"context"
 
-	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type Logger interface {
@@ -24,16 +24,99 @@ type Logger interface {
 	Panic(msg string, fields ...zap.Field)
 	Fatal(msg string, fields ...zap.Field)
 
+	// With 返回带有 fields 的子 logger
+	With(fields ...zap.Field) Logger
+
+	// Named 返回名为 name 的子 logger，多级名称用 "." 连接
+	Named(name string) Logger
+
 	// 提供 Sugar() 方法，返回 SugaredLogger 接口
 	Sugar() SugaredLogger
 }
 
 type logger struct {
 	zapLogger *zap.Logger
+	// base 是未经 level 过滤的 logger，Named 基于它重新包装
+	base *zap.Logger
+	name string
+	opts *options
+	// bound 是 FromContext 已绑定的 context 字段，*Ctx 调用时不再重复添加
+	bound *fieldSet
+}
+
+func NewLogger(zapLogger *zap.Logger, opts ...Option) Logger {
+	o := defaultOptions()
+	o.apply(opts...)
+	if o.levels != nil {
+		prefix := zapLogger.Name()
+		zapLogger = zapLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &floorCore{Core: core, levels: o.levels, prefix: prefix}
+		}))
+	}
+	if len(o.cores) > 0 {
+		zapLogger = zapLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(append([]zapcore.Core{core}, o.cores...)...)
+		}))
+	}
+	if o.redactor != nil {
+		zapLogger = zapLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &redactCore{Core: core, redactor: o.redactor}
+		}))
+	}
+	if len(o.sampling) > 0 {
+		zapLogger = zapLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newSamplingCore(core, o.sampling)
+		}))
+	}
+	if o.dedupInterval > 0 {
+		zapLogger = zapLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newDedupCore(core, o.dedupInterval)
+		}))
+	}
+	return newLogger(zapLogger, "", o)
 }
 
-func NewLogger(zapLogger *zap.Logger) Logger {
-	return &logger{zapLogger: zapLogger}
+func newLogger(base *zap.Logger, name string, o *options) *logger {
+	l := &logger{zapLogger: base, base: base, name: name, opts: o}
+	if o.levels != nil {
+		level := o.levels.Level(name)
+		l.zapLogger = base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &levelCore{Core: core, level: level}
+		}))
+	}
+	return l
+}
+
+func (l *logger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	fullName := name
+	if l.name != "" {
+		fullName = l.name + "." + name
+	}
+	child := newLogger(l.base.Named(name), fullName, l.opts)
+	child.bound = l.bound
+	return child
+}
+
+func (l *logger) With(fields ...zap.Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	child := newLogger(l.base.With(fields...), l.name, l.opts)
+	child.bound = l.bound
+	return child
+}
+
+// bind 返回带有 set 中字段的子 logger
+func (l *logger) bind(set *fieldSet) *logger {
+	if set == l.bound {
+		return l
+	}
+	child := newLogger(l.base.With(collectFields(set, l.bound)...), l.name, l.opts)
+	child.bound = set
+	return child
 }
 
 func (l *logger) Debug(msg string, fields ...zap.Field) {
@@ -94,19 +177,21 @@ func (l *logger) FatalCtx(ctx context.Context, msg string, fields ...zap.Field)
 
 // 根据需要实现其他方法
 
-// withContext 从 context 中提取 traceID，并添加到日志字段中
+// withContext 添加 WithFields 附加的字段和提取器从 context 中提取的字段
 func (l *logger) withContext(ctx context.Context, fields ...zap.Field) []zap.Field {
 	if ctx == nil {
 		return fields
 	}
 
-	span := trace.SpanFromContext(ctx)
-	if span.SpanContext().IsValid() {
-		traceID := span.SpanContext().TraceID().String()
-		fields = append(fields, zap.String("trace_id", traceID))
+	if set, _ := ctx.Value(fieldsKey{}).(*fieldSet); set != nil {
+		fields = append(fields, collectFields(set, l.bound)...)
+	}
+	for _, extract := range l.opts.extractors {
+		fields = append(fields, extract(ctx)...)
+	}
+	if l.opts.carryContext {
+		fields = append(fields, contextField(ctx))
 	}
-
-	// 如果有其他需要从 context 中提取的字段，可以在这里处理
 
 	return fields
 }
@@ -114,5 +199,6 @@ func (l *logger) withContext(ctx context.Context, fields ...zap.Field) []zap.Fie
 func (l *logger) Sugar() SugaredLogger {
 	return &sugaredLogger{
 		zapSugaredLogger: l.zapLogger.Sugar(),
+		logger:           l,
 	}
 }
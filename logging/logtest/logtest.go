@@ -0,0 +1,258 @@
+// Package logtest 提供测试用的 logging.Logger，记录所有日志以供查询和断言，
+// 日志内容只在测试失败时输出
+package logtest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/duolacloud/micro/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Option 设置 logtest 选项
+type Option func(*options)
+
+type options struct {
+	level      zapcore.LevelEnabler
+	loggerOpts []logging.Option
+}
+
+// WithLevel 设置记录的最低级别，默认 Debug
+func WithLevel(level zapcore.LevelEnabler) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithLoggerOptions 传给 logging.NewLogger 的选项
+func WithLoggerOptions(opts ...logging.Option) Option {
+	return func(o *options) {
+		o.loggerOpts = append(o.loggerOpts, opts...)
+	}
+}
+
+// Record 是一条日志记录
+type Record struct {
+	Level      zapcore.Level
+	Message    string
+	LoggerName string
+	// Fields 是编码后的字段，如 zap.Int 对应 int64
+	Fields map[string]interface{}
+}
+
+// TraceID 返回记录的 trace_id，没有时为空
+func (r Record) TraceID() string {
+	id, _ := r.Fields["trace_id"].(string)
+	return id
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("%s %q %v", r.Level, r.Message, r.Fields)
+}
+
+// Recorder 保存 logger 写入的日志
+type Recorder struct {
+	logs *observer.ObservedLogs
+}
+
+// New 返回写入 Recorder 的 Logger，t 失败时在 Cleanup 中输出全部日志
+func New(t testing.TB, opts ...Option) (logging.Logger, *Recorder) {
+	t.Helper()
+	o := &options{level: zapcore.DebugLevel}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	core, logs := observer.New(o.level)
+	buf := &syncBuffer{}
+	encoder := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	output := zapcore.NewCore(encoder, zapcore.AddSync(buf), o.level)
+
+	t.Cleanup(func() {
+		if t.Failed() && buf.Len() > 0 {
+			t.Logf("logs:\n%s", buf.String())
+		}
+	})
+
+	// panic 和 fatal 不退出进程，交给测试处理
+	// caller 跳过 logging.Logger 的包装方法，指向测试代码
+	zapLogger := zap.New(zapcore.NewTee(core, output), zap.AddCaller(), zap.AddCallerSkip(1), zap.WithFatalHook(zapcore.WriteThenPanic))
+	return logging.NewLogger(zapLogger, o.loggerOpts...), &Recorder{logs: logs}
+}
+
+// Records 返回所有记录
+func (r *Recorder) Records() []Record {
+	return toRecords(r.logs.All())
+}
+
+// Len 返回记录数
+func (r *Recorder) Len() int {
+	return r.logs.Len()
+}
+
+// Reset 清空记录
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// Filter 返回 fn 为 true 的记录
+func (r *Recorder) Filter(fn func(Record) bool) []Record {
+	var records []Record
+	for _, rec := range r.Records() {
+		if fn(rec) {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// FilterLevel 返回级别为 level 的记录
+func (r *Recorder) FilterLevel(level zapcore.Level) []Record {
+	return r.Filter(func(rec Record) bool { return rec.Level == level })
+}
+
+// FilterMessage 返回消息为 msg 的记录
+func (r *Recorder) FilterMessage(msg string) []Record {
+	return r.Filter(func(rec Record) bool { return rec.Message == msg })
+}
+
+// FilterField 返回字段 key 等于 value 的记录，value 按 zap.Any 编码后比较
+func (r *Recorder) FilterField(key string, value interface{}) []Record {
+	want := encodeValue(key, value)
+	return r.Filter(func(rec Record) bool {
+		got, ok := rec.Fields[key]
+		return ok && reflect.DeepEqual(got, want)
+	})
+}
+
+// AssertLogged 断言存在 level 和 msg 匹配的记录并返回第一条
+func (r *Recorder) AssertLogged(t testing.TB, level zapcore.Level, msg string) Record {
+	t.Helper()
+	for _, rec := range r.Records() {
+		if rec.Level == level && rec.Message == msg {
+			return rec
+		}
+	}
+	t.Errorf("no %s log %q, got:\n%s", level, msg, r.dump())
+	return Record{Fields: map[string]interface{}{}}
+}
+
+// AssertNotLogged 断言不存在 level 和 msg 匹配的记录
+func (r *Recorder) AssertNotLogged(t testing.TB, level zapcore.Level, msg string) {
+	t.Helper()
+	for _, rec := range r.Records() {
+		if rec.Level == level && rec.Message == msg {
+			t.Errorf("unexpected log %s", rec)
+		}
+	}
+}
+
+// AssertLen 断言记录数为 n
+func (r *Recorder) AssertLen(t testing.TB, n int) {
+	t.Helper()
+	if got := r.Len(); got != n {
+		t.Errorf("got %d logs, want %d:\n%s", got, n, r.dump())
+	}
+}
+
+// AssertField 断言 rec 的字段 key 等于 value，value 按 zap.Any 编码后比较
+func AssertField(t testing.TB, rec Record, key string, value interface{}) {
+	t.Helper()
+	got, ok := rec.Fields[key]
+	if !ok {
+		t.Errorf("log %q has no field %q: %v", rec.Message, key, rec.Fields)
+		return
+	}
+	if want := encodeValue(key, value); !reflect.DeepEqual(got, want) {
+		t.Errorf("log %q field %q = %#v, want %#v", rec.Message, key, got, want)
+	}
+}
+
+// AssertNoField 断言 rec 没有字段 key
+func AssertNoField(t testing.TB, rec Record, key string) {
+	t.Helper()
+	if got, ok := rec.Fields[key]; ok {
+		t.Errorf("log %q has field %q = %#v", rec.Message, key, got)
+	}
+}
+
+// AssertTraceID 断言 rec 带有 trace_id，want 非空时还要求相等
+func AssertTraceID(t testing.TB, rec Record, want string) {
+	t.Helper()
+	got := rec.TraceID()
+	switch {
+	case got == "":
+		t.Errorf("log %q has no trace_id", rec.Message)
+	case want != "" && got != want:
+		t.Errorf("log %q trace_id = %s, want %s", rec.Message, got, want)
+	}
+}
+
+// AssertNoTraceID 断言 rec 不带 trace_id
+func AssertNoTraceID(t testing.TB, rec Record) {
+	t.Helper()
+	if got := rec.TraceID(); got != "" {
+		t.Errorf("log %q has trace_id %s", rec.Message, got)
+	}
+}
+
+func (r *Recorder) dump() string {
+	var b strings.Builder
+	for _, rec := range r.Records() {
+		b.WriteString("  ")
+		b.WriteString(rec.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func toRecords(entries []observer.LoggedEntry) []Record {
+	records := make([]Record, len(entries))
+	for i, e := range entries {
+		// ContextMap 会跳过 SkipType 字段，如 logging 内部携带 context 的字段
+		records[i] = Record{
+			Level:      e.Level,
+			Message:    e.Message,
+			LoggerName: e.LoggerName,
+			Fields:     e.ContextMap(),
+		}
+	}
+	return records
+}
+
+// encodeValue 将 value 按日志字段的方式编码，便于与 Record.Fields 比较
+func encodeValue(key string, value interface{}) interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	zap.Any(key, value).AddTo(enc)
+	return enc.Fields[key]
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
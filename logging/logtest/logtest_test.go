@@ -0,0 +1,87 @@
+package logtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/duolacloud/micro/logging"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeT 记录失败信息而不让外层测试失败
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, format)
+}
+
+func TestRecorder(t *testing.T) {
+	l, rec := New(t, WithLevel(zapcore.InfoLevel))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	l.Debug("hidden")
+	l.InfoCtx(ctx, "created", zap.Int("id", 7), zap.String("name", "book"))
+	l.Named("store").Warn("slow", zap.Error(errors.New("timeout")))
+
+	rec.AssertLen(t, 2)
+	rec.AssertNotLogged(t, zapcore.DebugLevel, "hidden")
+
+	created := rec.AssertLogged(t, zapcore.InfoLevel, "created")
+	AssertField(t, created, "id", 7)
+	AssertField(t, created, "name", "book")
+	AssertTraceID(t, created, sc.TraceID().String())
+
+	slow := rec.AssertLogged(t, zapcore.WarnLevel, "slow")
+	AssertField(t, slow, "error", "timeout")
+	AssertNoTraceID(t, slow)
+	if slow.LoggerName != "store" {
+		t.Fatalf("logger name = %q", slow.LoggerName)
+	}
+
+	if got := rec.FilterField("id", 7); len(got) != 1 {
+		t.Fatalf("FilterField = %v", got)
+	}
+	rec.Reset()
+	rec.AssertLen(t, 0)
+}
+
+func TestRecorderCarryContext(t *testing.T) {
+	l, rec := New(t, WithLoggerOptions(logging.WithSpanEvents(zapcore.InfoLevel)))
+	l.InfoCtx(context.Background(), "hello")
+
+	// 携带 context 的内部字段不应出现在 Fields 中
+	got := rec.AssertLogged(t, zapcore.InfoLevel, "hello")
+	if len(got.Fields) != 0 {
+		t.Fatalf("fields = %v", got.Fields)
+	}
+}
+
+func TestAssertionsFail(t *testing.T) {
+	l, rec := New(t)
+	l.Info("hello", zap.Int("id", 1))
+
+	ft := &fakeT{TB: t}
+	rec.AssertLogged(ft, zapcore.ErrorLevel, "hello")
+	rec.AssertLen(ft, 2)
+	got := rec.AssertLogged(ft, zapcore.InfoLevel, "hello")
+	AssertField(ft, got, "id", 2)
+	AssertField(ft, got, "missing", 1)
+	AssertTraceID(ft, got, "")
+	if len(ft.errors) != 5 {
+		t.Fatalf("errors = %v", ft.errors)
+	}
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Option 设置 logger 选项
+type Option func(*options)
+
+type options struct {
+	levels     *Levels
+	extractors []ContextExtractor
+	// cores 与原 core 一起写入，例如 OTel 导出和 span 事件
+	cores []zapcore.Core
+	// carryContext 为 true 时 *Ctx 方法通过 contextField 将 context 传给 cores
+	carryContext  bool
+	redactor      *redactor
+	sampling      map[zapcore.Level]samplingConfig
+	dedupInterval time.Duration
+}
+
+func defaultOptions() *options {
+	return &options{
+		extractors: []ContextExtractor{TraceIDExtractor},
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithLevels 使用 levels 控制 logger 及其 Named 子 logger 的 level
+func WithLevels(levels *Levels) Option {
+	return func(o *options) {
+		o.levels = levels
+	}
+}
+
+// WithContextExtractors 追加 context 字段提取器，默认只提取 trace_id
+func WithContextExtractors(extractors ...ContextExtractor) Option {
+	return func(o *options) {
+		o.extractors = append(o.extractors, extractors...)
+	}
+}
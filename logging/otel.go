@@ -0,0 +1,281 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const otelScope = "github.com/duolacloud/micro/logging"
+
+// WithOTel 将 level 及以上的日志通过 OpenTelemetry Logs SDK 导出，
+// *Ctx 方法写入的日志带有 trace_id 和 span_id
+func WithOTel(provider otellog.LoggerProvider, level zapcore.LevelEnabler) Option {
+	return func(o *options) {
+		o.cores = append(o.cores, NewOTelCore(provider, level))
+		o.carryContext = true
+	}
+}
+
+// WithSpanEvents 将 level 及以上的 *Ctx 日志同时记录为当前 span 的事件，通常为 zapcore.WarnLevel
+func WithSpanEvents(level zapcore.LevelEnabler) Option {
+	return func(o *options) {
+		o.cores = append(o.cores, &spanEventCore{level: level})
+		o.carryContext = true
+	}
+}
+
+// NewOTLPLoggerProvider 创建通过 OTLP/gRPC 批量导出的 LoggerProvider，与 trace 一样读取
+// OTEL_EXPORTER_OTLP_* 环境变量，res 为 nil 时使用 resource.Default()。
+// 退出前需要调用 Shutdown 刷新缓冲
+func NewOTLPLoggerProvider(ctx context.Context, res *resource.Resource, opts ...otlploggrpc.Option) (*sdklog.LoggerProvider, error) {
+	exporter, err := otlploggrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = resource.Default()
+	}
+	return sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	), nil
+}
+
+// NewStdoutLoggerProvider 创建同步写入 w 的 LoggerProvider，用于测试或本地调试，w 可以是文件
+func NewStdoutLoggerProvider(w io.Writer) (*sdklog.LoggerProvider, error) {
+	exporter, err := stdoutlog.New(stdoutlog.WithWriter(w))
+	if err != nil {
+		return nil, err
+	}
+	return sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter))), nil
+}
+
+// contextField 携带 *Ctx 调用的 context，编码器会忽略 SkipType 字段
+func contextField(ctx context.Context) zap.Field {
+	return zap.Field{Type: zapcore.SkipType, Interface: ctx}
+}
+
+// contextOf 返回 contextField 携带的 context
+func contextOf(fields []zapcore.Field) context.Context {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Type != zapcore.SkipType {
+			continue
+		}
+		if ctx, ok := fields[i].Interface.(context.Context); ok {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
+// encodeFields 借助 MapObjectEncoder 将字段转换为 map，Namespace 会变成嵌套 map
+func encodeFields(fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// otelCore 通过 OpenTelemetry Logs SDK 导出日志
+type otelCore struct {
+	logger otellog.Logger
+	level  zapcore.LevelEnabler
+	fields []zapcore.Field
+}
+
+// NewOTelCore 返回通过 provider 导出 level 及以上日志的 zapcore.Core
+func NewOTelCore(provider otellog.LoggerProvider, level zapcore.LevelEnabler) zapcore.Core {
+	return &otelCore{logger: provider.Logger(otelScope), level: level}
+}
+
+func (c *otelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *otelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &otelCore{
+		logger: c.logger,
+		level:  c.level,
+		fields: append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *otelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *otelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var record otellog.Record
+	record.SetTimestamp(ent.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(otelSeverity(ent.Level))
+	record.SetSeverityText(ent.Level.CapitalString())
+	record.SetBody(otellog.StringValue(ent.Message))
+
+	if ent.LoggerName != "" {
+		record.AddAttributes(otellog.String("logger", ent.LoggerName))
+	}
+	if ent.Caller.Defined {
+		record.AddAttributes(
+			otellog.String("code.filepath", ent.Caller.File),
+			otellog.Int("code.lineno", ent.Caller.Line),
+			otellog.String("code.function", ent.Caller.Function),
+		)
+	}
+	if ent.Stack != "" {
+		record.AddAttributes(otellog.String("exception.stacktrace", ent.Stack))
+	}
+
+	all := append(append([]zapcore.Field(nil), c.fields...), fields...)
+	for key, value := range encodeFields(all) {
+		record.AddAttributes(otellog.KeyValue{Key: key, Value: otelValue(value)})
+	}
+
+	// SDK 从 context 中读取 trace_id 和 span_id
+	c.logger.Emit(contextOf(fields), record)
+	return nil
+}
+
+func (c *otelCore) Sync() error {
+	return nil
+}
+
+func otelSeverity(level zapcore.Level) otellog.Severity {
+	switch level {
+	case zapcore.DebugLevel:
+		return otellog.SeverityDebug
+	case zapcore.InfoLevel:
+		return otellog.SeverityInfo
+	case zapcore.WarnLevel:
+		return otellog.SeverityWarn
+	case zapcore.ErrorLevel:
+		return otellog.SeverityError
+	case zapcore.DPanicLevel:
+		return otellog.SeverityError2
+	case zapcore.PanicLevel:
+		return otellog.SeverityFatal
+	default:
+		return otellog.SeverityFatal2
+	}
+}
+
+func otelValue(v interface{}) otellog.Value {
+	switch v := v.(type) {
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case int:
+		return otellog.IntValue(v)
+	case int64:
+		return otellog.Int64Value(v)
+	case int32:
+		return otellog.Int64Value(int64(v))
+	case uint32:
+		return otellog.Int64Value(int64(v))
+	case float64:
+		return otellog.Float64Value(v)
+	case float32:
+		return otellog.Float64Value(float64(v))
+	case []byte:
+		return otellog.BytesValue(v)
+	case map[string]interface{}:
+		kvs := make([]otellog.KeyValue, 0, len(v))
+		for key, value := range v {
+			kvs = append(kvs, otellog.KeyValue{Key: key, Value: otelValue(value)})
+		}
+		return otellog.MapValue(kvs...)
+	case []interface{}:
+		values := make([]otellog.Value, 0, len(v))
+		for _, value := range v {
+			values = append(values, otelValue(value))
+		}
+		return otellog.SliceValue(values...)
+	default:
+		return otellog.StringValue(fmt.Sprint(v))
+	}
+}
+
+// spanEventCore 将日志记录为当前 span 的事件
+type spanEventCore struct {
+	level  zapcore.LevelEnabler
+	fields []zapcore.Field
+}
+
+func (c *spanEventCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *spanEventCore) With(fields []zapcore.Field) zapcore.Core {
+	return &spanEventCore{
+		level:  c.level,
+		fields: append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *spanEventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *spanEventCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	span := trace.SpanFromContext(contextOf(fields))
+	if !span.IsRecording() {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("log.severity", ent.Level.CapitalString()),
+		attribute.String("log.message", ent.Message),
+	}
+	all := append(append([]zapcore.Field(nil), c.fields...), fields...)
+	attrs = appendAttributes(attrs, "", encodeFields(all))
+	span.AddEvent("log", trace.WithTimestamp(ent.Time), trace.WithAttributes(attrs...))
+	return nil
+}
+
+func (c *spanEventCore) Sync() error {
+	return nil
+}
+
+// appendAttributes 将嵌套 map 展开为以 "." 连接的属性
+func appendAttributes(attrs []attribute.KeyValue, prefix string, m map[string]interface{}) []attribute.KeyValue {
+	for key, value := range m {
+		key = prefix + key
+		switch v := value.(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(key, v))
+		case int:
+			attrs = append(attrs, attribute.Int(key, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, v))
+		case map[string]interface{}:
+			attrs = appendAttributes(attrs, key+".", v)
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+	return attrs
+}
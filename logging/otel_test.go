@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestOTelExport(t *testing.T) {
+	var buf bytes.Buffer
+	provider, err := NewStdoutLoggerProvider(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core), WithOTel(provider, zapcore.InfoLevel), WithSpanEvents(zapcore.WarnLevel))
+
+	l.DebugCtx(ctx, "not exported")
+	l.InfoCtx(ctx, "exported", zap.String("user", "bob"))
+	l.WarnCtx(ctx, "mirrored", zap.Int("attempt", 2))
+	span.End()
+
+	out := buf.String()
+	if strings.Contains(out, "not exported") || !strings.Contains(out, "exported") || !strings.Contains(out, "bob") {
+		t.Fatalf("unexpected export %s", out)
+	}
+	if !strings.Contains(out, span.SpanContext().TraceID().String()) {
+		t.Fatalf("export is missing the trace id: %s", out)
+	}
+
+	events := recorder.Ended()[0].Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 span event, got %d", len(events))
+	}
+	attrs := map[string]string{}
+	for _, attr := range events[0].Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["log.message"] != "mirrored" || attrs["attempt"] != "2" {
+		t.Fatalf("unexpected event attributes %v", attrs)
+	}
+
+	// the carried context is not encoded by other cores
+	if _, ok := logs.All()[1].ContextMap()[""]; ok {
+		t.Fatal("context carrier leaked into fields")
+	}
+}
@@ -0,0 +1,314 @@
+package logging
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DefaultRedactKeys 是默认脱敏的字段名，大小写不敏感的 path.Match 模式
+var DefaultRedactKeys = []string{
+	"*password*", "*passwd*", "*secret*", "*token*",
+	"authorization", "cookie", "set-cookie", "*api_key*", "*apikey*",
+}
+
+var (
+	// creditCardPattern 匹配 13 到 19 位可带空格或横线的卡号，再经过 Luhn 校验
+	creditCardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// RedactOption 设置脱敏选项
+type RedactOption func(*redactor)
+
+// WithRedactKeys 追加需要脱敏的字段名模式，嵌套对象中的字段同样生效
+func WithRedactKeys(patterns ...string) RedactOption {
+	return func(r *redactor) {
+		for _, pattern := range patterns {
+			r.keys = append(r.keys, strings.ToLower(pattern))
+		}
+	}
+}
+
+// WithRedactRegexp 将消息和字符串字段中匹配 re 的内容替换为 replacement
+func WithRedactRegexp(re *regexp.Regexp, replacement string) RedactOption {
+	return func(r *redactor) {
+		r.scrubbers = append(r.scrubbers, scrubber{re: re, replace: func(string) string { return replacement }})
+	}
+}
+
+// WithRedactMask 设置替换敏感值的字符串，默认 "[REDACTED]"
+func WithRedactMask(mask string) RedactOption {
+	return func(r *redactor) {
+		r.mask = mask
+	}
+}
+
+// WithRedaction 对字段和消息脱敏：按字段名模式遮盖，遵循 proto 字段的 debug_redact 选项，
+// 并清除消息和字符串字段中的卡号和邮箱。脱敏在 core 中完成，对 *Ctx 和 Sugar 方法同样生效
+func WithRedaction(opts ...RedactOption) Option {
+	return func(o *options) {
+		r := &redactor{mask: "[REDACTED]"}
+		WithRedactKeys(DefaultRedactKeys...)(r)
+		r.scrubbers = []scrubber{
+			{re: creditCardPattern, replace: func(s string) string {
+				if luhn(s) {
+					return "[CARD]"
+				}
+				return s
+			}},
+			{re: emailPattern, replace: func(string) string { return "[EMAIL]" }},
+		}
+		for _, opt := range opts {
+			opt(r)
+		}
+		o.redactor = r
+	}
+}
+
+type scrubber struct {
+	re      *regexp.Regexp
+	replace func(string) string
+}
+
+type redactor struct {
+	keys      []string
+	scrubbers []scrubber
+	mask      string
+}
+
+func (r *redactor) matchKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) scrub(s string) string {
+	for _, sc := range r.scrubbers {
+		s = sc.re.ReplaceAllStringFunc(s, sc.replace)
+	}
+	return s
+}
+
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		out[i] = r.field(field)
+	}
+	return out
+}
+
+func (r *redactor) field(f zapcore.Field) zapcore.Field {
+	if f.Type == zapcore.SkipType || f.Type == zapcore.NamespaceType {
+		return f
+	}
+	if r.matchKey(f.Key) {
+		return zap.String(f.Key, r.mask)
+	}
+	// 生成的 proto 消息实现了 String()，zap.Any 会把它当作 Stringer
+	if msg, ok := f.Interface.(proto.Message); ok {
+		return zap.Any(f.Key, r.message(msg.ProtoReflect()))
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, r.scrub(f.String))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, r.scrub(err.Error()))
+		}
+	case zapcore.StringerType:
+		return zap.String(f.Key, r.scrub(stringOf(f)))
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType:
+		if f.Type == zapcore.InlineMarshalerType {
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			return zap.Inline(redactedObject(r.value(enc.Fields).(map[string]interface{})))
+		}
+		return zap.Any(f.Key, r.value(plain(f)))
+	}
+	return f
+}
+
+// plain 将对象字段转换为 map、slice 等基本类型，便于逐个字段脱敏
+func plain(f zapcore.Field) interface{} {
+	if f.Type == zapcore.ReflectType {
+		data, err := json.Marshal(f.Interface)
+		if err != nil {
+			return f.Interface
+		}
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return f.Interface
+		}
+		return v
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return enc.Fields[f.Key]
+}
+
+func stringOf(f zapcore.Field) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = "<panic>"
+		}
+	}()
+	return f.Interface.(interface{ String() string }).String()
+}
+
+// value 遍历嵌套的 map 和 slice，遮盖匹配的字段名并清除字符串
+func (r *redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.scrub(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if r.matchKey(key) {
+				out[key] = r.mask
+				continue
+			}
+			out[key] = r.value(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = r.value(value)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// message 将 proto 消息转换为 map，遮盖带有 debug_redact 选项或名称匹配的字段
+func (r *redactor) message(m protoreflect.Message) map[string]interface{} {
+	if !m.IsValid() {
+		return nil
+	}
+	out := map[string]interface{}{}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := fd.TextName()
+		if debugRedact(fd) || r.matchKey(name) {
+			out[name] = r.mask
+			return true
+		}
+		switch {
+		case fd.IsList():
+			list := v.List()
+			items := make([]interface{}, list.Len())
+			for i := range items {
+				items[i] = r.protoValue(fd, list.Get(i))
+			}
+			out[name] = items
+		case fd.IsMap():
+			entries := map[string]interface{}{}
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				entries[k.String()] = r.protoValue(fd.MapValue(), mv)
+				return true
+			})
+			out[name] = entries
+		default:
+			out[name] = r.protoValue(fd, v)
+		}
+		return true
+	})
+	return out
+}
+
+func (r *redactor) protoValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return r.message(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	case protoreflect.StringKind:
+		return r.scrub(v.String())
+	default:
+		return v.Interface()
+	}
+}
+
+func debugRedact(fd protoreflect.FieldDescriptor) bool {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	return ok && opts.GetDebugRedact()
+}
+
+// redactedObject 将脱敏后的 map 重新编码为内联对象
+type redactedObject map[string]interface{}
+
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for key, value := range o {
+		zap.Any(key, value).AddTo(enc)
+	}
+	return nil
+}
+
+// luhn 校验卡号，减少把普通数字当成卡号的误判
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// redactCore 在写入前对消息和字段脱敏
+type redactCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.scrub(ent.Message)
+	fields = c.redactor.fields(fields)
+
+	// 底层 core 已不接受 ent 的 level 时（例如 Check 之后 Levels 被调高）直接写
+	if !c.Core.Enabled(ent.Level) {
+		return c.Core.Write(ent, fields)
+	}
+	// 否则重新经过底层 core 的 Check，保留 tee、采样等过滤
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
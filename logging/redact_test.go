@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestRedaction(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core), WithRedaction())
+
+	type request struct {
+		Name  string `json:"name"`
+		Token string `json:"token"`
+	}
+	l.Info("card 4111 1111 1111 1111 from bob@example.com, order 1234567890123",
+		zap.String("password", "hunter2"),
+		zap.Any("req", request{Name: "bob", Token: "abc"}),
+	)
+	l.Sugar().Desugar().Info("call", zap.String("Authorization", "Bearer abc"))
+	l.Sugar().Infof("mail %s", "bob@example.com")
+
+	entry := logs.All()[0]
+	if entry.Message != "card [CARD] from [EMAIL], order 1234567890123" {
+		t.Fatalf("message not scrubbed: %q", entry.Message)
+	}
+	fields := entry.ContextMap()
+	req, _ := fields["req"].(map[string]interface{})
+	if fields["password"] != "[REDACTED]" || req["token"] != "[REDACTED]" || req["name"] != "bob" {
+		t.Fatalf("fields not redacted: %v", fields)
+	}
+	if got := logs.All()[1].ContextMap()["Authorization"]; got != "[REDACTED]" {
+		t.Fatalf("field not redacted: %v", got)
+	}
+	if got := logs.All()[2].Message; got != "mail [EMAIL]" {
+		t.Fatalf("sugared message not scrubbed: %q", got)
+	}
+}
+
+func TestRedactProto(t *testing.T) {
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("redact_test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Login"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("user"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("user")},
+				{
+					Name: proto.String("otp"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("otp"),
+					Options: &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)},
+				},
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := fd.Messages().ByName("Login")
+	msg := dynamicpb.NewMessage(md)
+	msg.Set(md.Fields().ByName("user"), protoValue("bob"))
+	msg.Set(md.Fields().ByName("otp"), protoValue("123456"))
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	NewLogger(zap.New(core), WithRedaction()).Info("login", zap.Any("req", msg))
+
+	req, _ := logs.All()[0].ContextMap()["req"].(map[string]interface{})
+	if req["user"] != "bob" || req["otp"] != "[REDACTED]" {
+		t.Fatalf("proto not redacted: %v", req)
+	}
+}
+
+func protoValue(s string) protoreflect.Value {
+	return protoreflect.ValueOfString(s)
+}
@@ -0,0 +1,219 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithSampling 对 level 的日志按消息采样：每个 interval 内同一消息先写 first 条，
+// 之后每 thereafter 条写一条，可以为不同 level 分别设置。属于已采样 trace 的 *Ctx 日志总是写入
+func WithSampling(level zapcore.Level, interval time.Duration, first, thereafter int) Option {
+	return func(o *options) {
+		if o.sampling == nil {
+			o.sampling = map[zapcore.Level]samplingConfig{}
+		}
+		o.sampling[level] = samplingConfig{interval: interval, first: first, thereafter: thereafter}
+		o.carryContext = true
+	}
+}
+
+// WithDedup 合并 interval 内相同 level 和消息的日志，只写第一条，其余在 interval 结束时
+// 汇总为一条 "repeated N times" 日志。属于已采样 trace 的 *Ctx 日志总是写入
+func WithDedup(interval time.Duration) Option {
+	return func(o *options) {
+		o.dedupInterval = interval
+		o.carryContext = true
+	}
+}
+
+type samplingConfig struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+}
+
+// sampledTrace 判断 *Ctx 日志是否属于已采样的 trace
+func sampledTrace(fields []zapcore.Field) bool {
+	return trace.SpanContextFromContext(contextOf(fields)).IsSampled()
+}
+
+// writeThrough 经过 core 的 Check 写入，保留其中的过滤；core 已不接受 ent 的
+// level 时（例如去重汇总写出前 Levels 被调高）直接写
+func writeThrough(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) error {
+	if !core.Enabled(ent.Level) {
+		return core.Write(ent, fields)
+	}
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// samplingCore 按 level 使用 zap sampler 采样
+type samplingCore struct {
+	zapcore.Core
+	samplers map[zapcore.Level]zapcore.Core
+}
+
+func newSamplingCore(core zapcore.Core, configs map[zapcore.Level]samplingConfig) *samplingCore {
+	samplers := make(map[zapcore.Level]zapcore.Core, len(configs))
+	for level, cfg := range configs {
+		samplers[level] = zapcore.NewSamplerWithOptions(core, cfg.interval, cfg.first, cfg.thereafter)
+	}
+	return &samplingCore{Core: core, samplers: samplers}
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	samplers := make(map[zapcore.Level]zapcore.Core, len(c.samplers))
+	for level, sampler := range c.samplers {
+		// sampler 的 With 共享计数
+		samplers[level] = sampler.With(fields)
+	}
+	return &samplingCore{Core: c.Core.With(fields), samplers: samplers}
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *samplingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	sampler, ok := c.samplers[ent.Level]
+	if !ok || sampledTrace(fields) {
+		return writeThrough(c.Core, ent, fields)
+	}
+	return writeThrough(sampler, ent, fields)
+}
+
+type dedupKey struct {
+	level   zapcore.Level
+	logger  string
+	message string
+}
+
+type dedupEntry struct {
+	start  time.Time
+	count  int
+	ent    zapcore.Entry
+	fields []zapcore.Field
+	core   zapcore.Core
+}
+
+// dedupState 在 With 派生的 core 之间共享
+type dedupState struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	entries   map[dedupKey]*dedupEntry
+	scheduled bool
+	timer     *time.Timer
+}
+
+// dedupCore 合并重复日志
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+func newDedupCore(core zapcore.Core, interval time.Duration) *dedupCore {
+	return &dedupCore{
+		Core:  core,
+		state: &dedupState{interval: interval, entries: map[dedupKey]*dedupEntry{}},
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if sampledTrace(fields) {
+		return writeThrough(c.Core, ent, fields)
+	}
+
+	s := c.state
+	key := dedupKey{level: ent.Level, logger: ent.LoggerName, message: ent.Message}
+	now := time.Now()
+
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	if ok && now.Sub(e.start) < s.interval {
+		e.count++
+		if !s.scheduled {
+			s.scheduled = true
+			s.timer = time.AfterFunc(s.interval, func() { s.flush(false) })
+		}
+		s.mu.Unlock()
+		return nil
+	}
+	var summary *dedupEntry
+	if ok && e.count > 0 {
+		summary = &dedupEntry{count: e.count, ent: e.ent, fields: e.fields, core: e.core}
+	}
+	s.entries[key] = &dedupEntry{start: now, ent: ent, fields: append([]zapcore.Field(nil), fields...), core: c.Core}
+	s.mu.Unlock()
+
+	if summary != nil {
+		summary.write()
+	}
+	return writeThrough(c.Core, ent, fields)
+}
+
+func (c *dedupCore) Sync() error {
+	c.state.flush(true)
+	return c.Core.Sync()
+}
+
+// flush 写出窗口已结束（all 为 true 时为全部）的汇总，并清理过期的记录
+func (s *dedupState) flush(all bool) {
+	now := time.Now()
+	var summaries []*dedupEntry
+
+	s.mu.Lock()
+	s.scheduled = false
+	// 全部写出后不再需要定时器，避免 Sync 之后（例如输出已关闭）再写
+	if all && s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	for key, e := range s.entries {
+		expired := now.Sub(e.start) >= s.interval
+		if e.count > 0 && (expired || all) {
+			summaries = append(summaries, &dedupEntry{count: e.count, ent: e.ent, fields: e.fields, core: e.core})
+			e.count = 0
+		}
+		if expired {
+			delete(s.entries, key)
+		} else if e.count > 0 && !s.scheduled {
+			s.scheduled = true
+			s.timer = time.AfterFunc(s.interval-now.Sub(e.start), func() { s.flush(false) })
+		}
+	}
+	s.mu.Unlock()
+
+	for _, summary := range summaries {
+		summary.write()
+	}
+}
+
+func (e *dedupEntry) write() {
+	ent := e.ent
+	ent.Time = time.Now()
+	ent.Message = fmt.Sprintf("%s (repeated %d times)", e.ent.Message, e.count)
+	fields := append(append([]zapcore.Field(nil), e.fields...), zap.Int("repeated", e.count))
+	_ = writeThrough(e.core, ent, fields)
+}
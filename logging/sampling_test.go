@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func sampledContext() context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core), WithSampling(zapcore.ErrorLevel, time.Minute, 2, 5))
+
+	for i := 0; i < 12; i++ {
+		l.Error("dependency down")
+		l.Info("not sampled")
+	}
+	// first 2, then the 7th and 12th
+	if got := len(logs.FilterMessage("dependency down").All()); got != 4 {
+		t.Fatalf("expected 4 sampled errors, got %d", got)
+	}
+	if got := len(logs.FilterMessage("not sampled").All()); got != 12 {
+		t.Fatalf("info should not be sampled, got %d", got)
+	}
+
+	ctx := sampledContext()
+	for i := 0; i < 5; i++ {
+		l.ErrorCtx(ctx, "dependency down")
+	}
+	if got := len(logs.FilterMessage("dependency down").All()); got != 9 {
+		t.Fatalf("sampled traces must be kept, got %d", got)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core), WithDedup(50*time.Millisecond))
+
+	for i := 0; i < 100; i++ {
+		l.ErrorCtx(context.Background(), "dependency down", zap.Int("i", i))
+	}
+	l.ErrorCtx(sampledContext(), "dependency down")
+	if logs.Len() != 2 {
+		t.Fatalf("expected first record and sampled trace record, got %d", logs.Len())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected a summary, got %d entries", len(entries))
+	}
+	if entries[2].Message != "dependency down (repeated 99 times)" || entries[2].ContextMap()["repeated"] != int64(99) {
+		t.Fatalf("unexpected summary %+v", entries[2])
+	}
+}
@@ -0,0 +1,282 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler 将 slog 记录写入 Logger，通过 *Ctx 方法附加 trace 等 context 字段
+type slogHandler struct {
+	logger Logger
+	// groups 是打开的 group，fields[i] 是 groups[i] 中已有的属性，
+	// 在 Handle 时与记录的属性一起组装为嵌套对象，这样 context 字段仍在顶层
+	groups []string
+	fields [][]zap.Field
+}
+
+// NewSlogHandler 返回写入 l 的 slog.Handler，可以用 slog.SetDefault(slog.New(h))
+// 让 slog 日志走同一条管道
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if impl, ok := h.logger.(*logger); ok {
+		return impl.zapLogger.Core().Enabled(zapLevel(level))
+	}
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]zap.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		if field, ok := zapField(attr); ok {
+			fields = append(fields, field)
+		}
+		return true
+	})
+	// 从最内层的 group 开始组装，空 group 按 slog 约定省略
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		inner := append(append([]zap.Field(nil), h.fields[i]...), fields...)
+		fields = nil
+		if len(inner) > 0 {
+			fields = []zap.Field{zap.Object(h.groups[i], zapFields(inner))}
+		}
+	}
+
+	if impl, ok := h.logger.(*logger); ok {
+		impl.writeSlog(ctx, record, fields)
+		return nil
+	}
+	switch zapLevel(record.Level) {
+	case zapcore.DebugLevel:
+		h.logger.DebugCtx(ctx, record.Message, fields...)
+	case zapcore.InfoLevel:
+		h.logger.InfoCtx(ctx, record.Message, fields...)
+	case zapcore.WarnLevel:
+		h.logger.WarnCtx(ctx, record.Message, fields...)
+	default:
+		h.logger.ErrorCtx(ctx, record.Message, fields...)
+	}
+	return nil
+}
+
+// writeSlog 写入 slog 记录，caller 和时间取自记录，而不是 slog 内部的调用位置
+func (l *logger) writeSlog(ctx context.Context, record slog.Record, fields []zap.Field) {
+	ce := l.zapLogger.Check(zapLevel(record.Level), record.Message)
+	if ce == nil {
+		return
+	}
+	if !record.Time.IsZero() {
+		ce.Time = record.Time
+	}
+	// 只在 logger 开启 caller 时替换
+	if ce.Caller.Defined && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ce.Caller.Function = frame.Function
+	}
+	ce.Write(l.withContext(ctx, fields...)...)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		if field, ok := zapField(attr); ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	if len(h.groups) == 0 {
+		return &slogHandler{logger: h.logger.With(fields...)}
+	}
+
+	c := h.clone()
+	last := len(c.fields) - 1
+	c.fields[last] = append(append([]zap.Field(nil), c.fields[last]...), fields...)
+	return c
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := h.clone()
+	c.groups = append(c.groups, name)
+	c.fields = append(c.fields, nil)
+	return c
+}
+
+func (h *slogHandler) clone() *slogHandler {
+	return &slogHandler{
+		logger: h.logger,
+		groups: append([]string(nil), h.groups...),
+		fields: append([][]zap.Field(nil), h.fields...),
+	}
+}
+
+// zapFields 将字段编码为对象
+type zapFields []zap.Field
+
+func (f zapFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, field := range f {
+		field.AddTo(enc)
+	}
+	return nil
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func slogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case level == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case level == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// zapField 转换 slog 属性，空属性按 slog 约定忽略
+func zapField(attr slog.Attr) (zap.Field, bool) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return zap.Field{}, false
+	}
+
+	v := attr.Value
+	switch v.Kind() {
+	case slog.KindString:
+		return zap.String(attr.Key, v.String()), true
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, v.Int64()), true
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, v.Uint64()), true
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, v.Float64()), true
+	case slog.KindBool:
+		return zap.Bool(attr.Key, v.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, v.Duration()), true
+	case slog.KindTime:
+		return zap.Time(attr.Key, v.Time()), true
+	case slog.KindGroup:
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return zap.Field{}, false
+		}
+		group := make(zapFields, 0, len(attrs))
+		for _, a := range attrs {
+			if field, ok := zapField(a); ok {
+				group = append(group, field)
+			}
+		}
+		// 没有 key 的 group 按 slog 约定展开，zap 中用 Inline 实现
+		if attr.Key == "" {
+			return zap.Inline(group), true
+		}
+		return zap.Object(attr.Key, group), true
+	default:
+		if err, ok := v.Any().(error); ok {
+			return zap.NamedError(attr.Key, err), true
+		}
+		return zap.Any(attr.Key, v.Any()), true
+	}
+}
+
+// slogCore 是写入 slog.Handler 的 zapcore.Core
+type slogCore struct {
+	handler slog.Handler
+}
+
+// NewSlogCore 返回写入 handler 的 zapcore.Core
+func NewSlogCore(handler slog.Handler) zapcore.Core {
+	return &slogCore{handler: handler}
+}
+
+// NewLoggerFromSlog 返回写入任意 slog.Handler 的 Logger
+func NewLoggerFromSlog(handler slog.Handler, opts ...Option) Logger {
+	return NewLogger(zap.New(NewSlogCore(handler), zap.AddCaller()), opts...)
+}
+
+func (c *slogCore) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), slogLevel(level))
+}
+
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core {
+	if len(fields) == 0 {
+		return c
+	}
+	return &slogCore{handler: c.handler.WithAttrs(slogAttrs(fields))}
+}
+
+func (c *slogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(ent.Time, slogLevel(ent.Level), ent.Message, ent.Caller.PC)
+	if ent.LoggerName != "" {
+		record.AddAttrs(slog.String("logger", ent.LoggerName))
+	}
+	record.AddAttrs(slogAttrs(fields)...)
+	if ent.Stack != "" {
+		record.AddAttrs(slog.String("stacktrace", ent.Stack))
+	}
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+// slogAttrs 借助 MapObjectEncoder 转换 zap 字段，Namespace 会变成嵌套的 group
+func slogAttrs(fields []zapcore.Field) []slog.Attr {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return mapAttrs(enc.Fields)
+}
+
+func mapAttrs(m map[string]interface{}) []slog.Attr {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(m))
+	for _, key := range keys {
+		if nested, ok := m[key].(map[string]interface{}); ok {
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(mapAttrs(nested)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, m[key]))
+	}
+	return attrs
+}
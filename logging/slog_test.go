@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := slog.New(NewSlogHandler(NewLogger(zap.New(core))))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	log.DebugContext(ctx, "dropped")
+	log.With("service", "api").WithGroup("req").InfoContext(ctx, "hello", "id", 7, slog.Group("user", "name", "bob"))
+	log.WarnContext(ctx, "careful")
+
+	if logs.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", logs.Len())
+	}
+	entry := logs.All()[0]
+	fields := entry.ContextMap()
+	req, _ := fields["req"].(map[string]interface{})
+	user, _ := req["user"].(map[string]interface{})
+	if fields["service"] != "api" || req["id"] != int64(7) || user["name"] != "bob" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if fields["trace_id"] != sc.TraceID().String() {
+		t.Fatalf("missing trace_id in %v", fields)
+	}
+	if logs.All()[1].Level != zapcore.WarnLevel {
+		t.Fatalf("unexpected level %s", logs.All()[1].Level)
+	}
+}
+
+func TestLoggerFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromSlog(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	l.Debug("dropped")
+	l.Named("redis").With(zap.String("addr", "localhost")).Warn("slow", zap.Namespace("cmd"), zap.String("name", "get"))
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	cmd, _ := record["cmd"].(map[string]interface{})
+	if record["level"] != "WARN" || record["msg"] != "slow" || record["logger"] != "redis" || record["addr"] != "localhost" || cmd["name"] != "get" {
+		t.Fatalf("unexpected record %v", record)
+	}
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := slog.New(NewSlogHandler(NewLogger(zap.New(core, zap.AddCaller()))))
+
+	_, file, line, _ := runtime.Caller(0)
+	log.Info("hello")
+
+	caller := logs.All()[0].Caller
+	if !caller.Defined || caller.File != file || caller.Line != line+1 {
+		t.Fatalf("caller = %s, want %s:%d", caller, file, line+1)
+	}
+	if !strings.HasSuffix(caller.Function, "TestSlogHandlerCaller") {
+		t.Fatalf("caller function = %q", caller.Function)
+	}
+}
@@ -2,9 +2,10 @@ package logging
 
 import (
 	"context"
+	"fmt"
 
-	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type SugaredLogger interface {
@@ -12,76 +13,209 @@ type SugaredLogger interface {
 	InfoCtx(ctx context.Context, args ...interface{})
 	WarnCtx(ctx context.Context, args ...interface{})
 	ErrorCtx(ctx context.Context, args ...interface{})
+	DPanicCtx(ctx context.Context, args ...interface{})
+	PanicCtx(ctx context.Context, args ...interface{})
+	FatalCtx(ctx context.Context, args ...interface{})
+
 	DebugfCtx(ctx context.Context, template string, args ...interface{})
 	InfofCtx(ctx context.Context, template string, args ...interface{})
 	WarnfCtx(ctx context.Context, template string, args ...interface{})
 	ErrorfCtx(ctx context.Context, template string, args ...interface{})
-	FatalfCtx(ctx context.Context, args ...interface{})
+	DPanicfCtx(ctx context.Context, template string, args ...interface{})
+	PanicfCtx(ctx context.Context, template string, args ...interface{})
+	FatalfCtx(ctx context.Context, template string, args ...interface{})
+
+	DebugwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
+	InfowCtx(ctx context.Context, msg string, keysAndValues ...interface{})
+	WarnwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
+	ErrorwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
+	DPanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
+	PanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
+	FatalwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
 
 	Debug(args ...interface{})
 	Info(args ...interface{})
 	Warn(args ...interface{})
 	Error(args ...interface{})
+	DPanic(args ...interface{})
+	Panic(args ...interface{})
+	Fatal(args ...interface{})
+
 	Debugf(template string, args ...interface{})
 	Infof(template string, args ...interface{})
 	Warnf(template string, args ...interface{})
 	Errorf(template string, args ...interface{})
-	Fatalf(args ...interface{})
+	DPanicf(template string, args ...interface{})
+	Panicf(template string, args ...interface{})
+	Fatalf(template string, args ...interface{})
+
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	DPanicw(msg string, keysAndValues ...interface{})
+	Panicw(msg string, keysAndValues ...interface{})
+	Fatalw(msg string, keysAndValues ...interface{})
+
+	// With 返回带有 args 的子 logger，args 为键值对或 zap.Field
+	With(args ...interface{}) SugaredLogger
+	// Named 返回名为 name 的子 logger
+	Named(name string) SugaredLogger
 
 	Desugar() Logger
 }
 
 type sugaredLogger struct {
 	zapSugaredLogger *zap.SugaredLogger
+	// logger 是创建它的 Logger，Desugar 时返回以保留名称和 level 设置
+	logger *logger
+}
+
+func NewSugaredLogger(zapLogger *zap.Logger, opts ...Option) SugaredLogger {
+	return NewLogger(zapLogger, opts...).Sugar()
+}
+
+// enabled 判断 level 是否写入，未启用时 *Ctx 方法不格式化消息也不提取 context 字段。
+// DPanic 及以上总是交给 zap，由它处理 panic 和退出
+func (l *sugaredLogger) enabled(level zapcore.Level) bool {
+	return level >= zapcore.DPanicLevel || l.logger.zapLogger.Core().Enabled(level)
 }
 
-func NewSugaredLogger(zapLogger *zap.Logger) SugaredLogger {
-	return &sugaredLogger{zapSugaredLogger: zapLogger.Sugar()}
+// ctxArgs 将 context 字段作为强类型字段追加到键值对之后，trace_id 等不会拼进消息
+func (l *sugaredLogger) ctxArgs(ctx context.Context, keysAndValues []interface{}) []interface{} {
+	fields := l.logger.withContext(ctx)
+	args := make([]interface{}, 0, len(keysAndValues)+len(fields))
+	args = append(args, keysAndValues...)
+	for _, field := range fields {
+		args = append(args, field)
+	}
+	return args
 }
 
 func (l *sugaredLogger) DebugCtx(ctx context.Context, args ...interface{}) {
-	args = l.withContext(ctx, args)
-	l.zapSugaredLogger.Debug(args...)
+	if l.enabled(zapcore.DebugLevel) {
+		l.zapSugaredLogger.Debugw(fmt.Sprint(args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
 func (l *sugaredLogger) InfoCtx(ctx context.Context, args ...interface{}) {
-	args = l.withContext(ctx, args)
-	l.zapSugaredLogger.Info(args...)
+	if l.enabled(zapcore.InfoLevel) {
+		l.zapSugaredLogger.Infow(fmt.Sprint(args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
 func (l *sugaredLogger) WarnCtx(ctx context.Context, args ...interface{}) {
-	args = l.withContext(ctx, args)
-	l.zapSugaredLogger.Warn(args...)
+	if l.enabled(zapcore.WarnLevel) {
+		l.zapSugaredLogger.Warnw(fmt.Sprint(args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
 func (l *sugaredLogger) ErrorCtx(ctx context.Context, args ...interface{}) {
-	args = l.withContext(ctx, args)
-	l.zapSugaredLogger.Error(args...)
+	if l.enabled(zapcore.ErrorLevel) {
+		l.zapSugaredLogger.Errorw(fmt.Sprint(args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
-func (l *sugaredLogger) FatalfCtx(ctx context.Context, args ...interface{}) {
-	args = l.withContext(ctx, args)
-	l.zapSugaredLogger.Error(args...)
+func (l *sugaredLogger) DPanicCtx(ctx context.Context, args ...interface{}) {
+	if l.enabled(zapcore.DPanicLevel) {
+		l.zapSugaredLogger.DPanicw(fmt.Sprint(args...), l.ctxArgs(ctx, nil)...)
+	}
+}
+
+func (l *sugaredLogger) PanicCtx(ctx context.Context, args ...interface{}) {
+	if l.enabled(zapcore.PanicLevel) {
+		l.zapSugaredLogger.Panicw(fmt.Sprint(args...), l.ctxArgs(ctx, nil)...)
+	}
+}
+
+func (l *sugaredLogger) FatalCtx(ctx context.Context, args ...interface{}) {
+	if l.enabled(zapcore.FatalLevel) {
+		l.zapSugaredLogger.Fatalw(fmt.Sprint(args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
 func (l *sugaredLogger) DebugfCtx(ctx context.Context, template string, args ...interface{}) {
-	template, args = l.withContextf(ctx, template, args)
-	l.zapSugaredLogger.Debugf(template, args...)
+	if l.enabled(zapcore.DebugLevel) {
+		l.zapSugaredLogger.Debugw(fmt.Sprintf(template, args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
 func (l *sugaredLogger) InfofCtx(ctx context.Context, template string, args ...interface{}) {
-	template, args = l.withContextf(ctx, template, args)
-	l.zapSugaredLogger.Infof(template, args...)
+	if l.enabled(zapcore.InfoLevel) {
+		l.zapSugaredLogger.Infow(fmt.Sprintf(template, args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
 func (l *sugaredLogger) WarnfCtx(ctx context.Context, template string, args ...interface{}) {
-	template, args = l.withContextf(ctx, template, args)
-	l.zapSugaredLogger.Warnf(template, args...)
+	if l.enabled(zapcore.WarnLevel) {
+		l.zapSugaredLogger.Warnw(fmt.Sprintf(template, args...), l.ctxArgs(ctx, nil)...)
+	}
 }
 
 func (l *sugaredLogger) ErrorfCtx(ctx context.Context, template string, args ...interface{}) {
-	template, args = l.withContextf(ctx, template, args)
-	l.zapSugaredLogger.Errorf(template, args...)
+	if l.enabled(zapcore.ErrorLevel) {
+		l.zapSugaredLogger.Errorw(fmt.Sprintf(template, args...), l.ctxArgs(ctx, nil)...)
+	}
+}
+
+func (l *sugaredLogger) DPanicfCtx(ctx context.Context, template string, args ...interface{}) {
+	if l.enabled(zapcore.DPanicLevel) {
+		l.zapSugaredLogger.DPanicw(fmt.Sprintf(template, args...), l.ctxArgs(ctx, nil)...)
+	}
+}
+
+func (l *sugaredLogger) PanicfCtx(ctx context.Context, template string, args ...interface{}) {
+	if l.enabled(zapcore.PanicLevel) {
+		l.zapSugaredLogger.Panicw(fmt.Sprintf(template, args...), l.ctxArgs(ctx, nil)...)
+	}
+}
+
+func (l *sugaredLogger) FatalfCtx(ctx context.Context, template string, args ...interface{}) {
+	if l.enabled(zapcore.FatalLevel) {
+		l.zapSugaredLogger.Fatalw(fmt.Sprintf(template, args...), l.ctxArgs(ctx, nil)...)
+	}
+}
+
+func (l *sugaredLogger) DebugwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if l.enabled(zapcore.DebugLevel) {
+		l.zapSugaredLogger.Debugw(msg, l.ctxArgs(ctx, keysAndValues)...)
+	}
+}
+
+func (l *sugaredLogger) InfowCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if l.enabled(zapcore.InfoLevel) {
+		l.zapSugaredLogger.Infow(msg, l.ctxArgs(ctx, keysAndValues)...)
+	}
+}
+
+func (l *sugaredLogger) WarnwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if l.enabled(zapcore.WarnLevel) {
+		l.zapSugaredLogger.Warnw(msg, l.ctxArgs(ctx, keysAndValues)...)
+	}
+}
+
+func (l *sugaredLogger) ErrorwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if l.enabled(zapcore.ErrorLevel) {
+		l.zapSugaredLogger.Errorw(msg, l.ctxArgs(ctx, keysAndValues)...)
+	}
+}
+
+func (l *sugaredLogger) DPanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if l.enabled(zapcore.DPanicLevel) {
+		l.zapSugaredLogger.DPanicw(msg, l.ctxArgs(ctx, keysAndValues)...)
+	}
+}
+
+func (l *sugaredLogger) PanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if l.enabled(zapcore.PanicLevel) {
+		l.zapSugaredLogger.Panicw(msg, l.ctxArgs(ctx, keysAndValues)...)
+	}
+}
+
+func (l *sugaredLogger) FatalwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if l.enabled(zapcore.FatalLevel) {
+		l.zapSugaredLogger.Fatalw(msg, l.ctxArgs(ctx, keysAndValues)...)
+	}
 }
 
 func (l *sugaredLogger) Debug(args ...interface{}) {
@@ -100,6 +234,18 @@ func (l *sugaredLogger) Error(args ...interface{}) {
 	l.zapSugaredLogger.Error(args...)
 }
 
+func (l *sugaredLogger) DPanic(args ...interface{}) {
+	l.zapSugaredLogger.DPanic(args...)
+}
+
+func (l *sugaredLogger) Panic(args ...interface{}) {
+	l.zapSugaredLogger.Panic(args...)
+}
+
+func (l *sugaredLogger) Fatal(args ...interface{}) {
+	l.zapSugaredLogger.Fatal(args...)
+}
+
 func (l *sugaredLogger) Debugf(template string, args ...interface{}) {
 	l.zapSugaredLogger.Debugf(template, args...)
 }
@@ -116,45 +262,61 @@ func (l *sugaredLogger) Errorf(template string, args ...interface{}) {
 	l.zapSugaredLogger.Errorf(template, args...)
 }
 
-func (l *sugaredLogger) Fatalf(args ...interface{}) {
-	l.zapSugaredLogger.Error(args...)
+func (l *sugaredLogger) DPanicf(template string, args ...interface{}) {
+	l.zapSugaredLogger.DPanicf(template, args...)
 }
 
-func (l *sugaredLogger) Desugar() Logger {
-	return &logger{
-		zapLogger: l.zapSugaredLogger.Desugar(),
-	}
+func (l *sugaredLogger) Panicf(template string, args ...interface{}) {
+	l.zapSugaredLogger.Panicf(template, args...)
 }
 
-// 辅助函数，用于在 args 中添加 trace_id
-func (l *sugaredLogger) withContext(ctx context.Context, args []interface{}) []interface{} {
-	if ctx == nil {
-		return args
-	}
+func (l *sugaredLogger) Fatalf(template string, args ...interface{}) {
+	l.zapSugaredLogger.Fatalf(template, args...)
+}
 
-	span := trace.SpanFromContext(ctx)
-	if span.SpanContext().IsValid() {
-		traceID := span.SpanContext().TraceID().String()
-		// 将 trace_id 添加到 args 中
-		args = append(args, "trace_id", traceID)
-	}
+func (l *sugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.zapSugaredLogger.Debugw(msg, keysAndValues...)
+}
 
-	return args
+func (l *sugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.zapSugaredLogger.Infow(msg, keysAndValues...)
 }
 
-// 辅助函数，用于在模板和 args 中添加 trace_id
-func (l *sugaredLogger) withContextf(ctx context.Context, template string, args []interface{}) (string, []interface{}) {
-	if ctx == nil {
-		return template, args
-	}
+func (l *sugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.zapSugaredLogger.Warnw(msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.zapSugaredLogger.Errorw(msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) DPanicw(msg string, keysAndValues ...interface{}) {
+	l.zapSugaredLogger.DPanicw(msg, keysAndValues...)
+}
 
-	span := trace.SpanFromContext(ctx)
-	if span.SpanContext().IsValid() {
-		traceID := span.SpanContext().TraceID().String()
-		// 修改模板和 args，将 trace_id 添加到日志中
-		template = template + " | trace_id: %s"
-		args = append(args, traceID)
+func (l *sugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	l.zapSugaredLogger.Panicw(msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.zapSugaredLogger.Fatalw(msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) With(args ...interface{}) SugaredLogger {
+	if len(args) == 0 {
+		return l
 	}
+	// 用 zap 解析键值对，再基于未经 level 包装的 base 创建子 logger
+	base := l.logger.base.Sugar().With(args...).Desugar()
+	child := newLogger(base, l.logger.name, l.logger.opts)
+	child.bound = l.logger.bound
+	return child.Sugar()
+}
 
-	return template, args
+func (l *sugaredLogger) Named(name string) SugaredLogger {
+	return l.logger.Named(name).Sugar()
+}
+
+func (l *sugaredLogger) Desugar() Logger {
+	return l.logger
 }
@@ -0,0 +1,141 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSugaredCtx(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s := NewLogger(zap.New(core)).Sugar()
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = WithFields(ctx, zap.String("request_id", "req-1"))
+
+	s.InfofCtx(ctx, "hello %s", "bob")
+	s.With("tenant", "acme").Named("orders").WarnwCtx(ctx, "slow", "elapsed_ms", 1200)
+
+	entries := logs.All()
+	if entries[0].Message != "hello bob" {
+		t.Fatalf("template was modified: %q", entries[0].Message)
+	}
+	fields := entries[0].ContextMap()
+	if fields["trace_id"] != sc.TraceID().String() || fields["request_id"] != "req-1" {
+		t.Fatalf("missing context fields: %v", fields)
+	}
+	fields = entries[1].ContextMap()
+	if entries[1].LoggerName != "orders" || fields["tenant"] != "acme" || fields["elapsed_ms"] != int64(1200) || fields["trace_id"] == nil {
+		t.Fatalf("unexpected entry %+v %v", entries[1], fields)
+	}
+}
+
+func TestSugaredFatalAndPanic(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s := NewLogger(zap.New(core, zap.WithFatalHook(zapcore.WriteThenPanic))).Sugar()
+
+	for _, fn := range []func(){
+		func() { s.Fatalf("exit %d", 1) },
+		func() { s.PanicwCtx(context.Background(), "boom", "key", "value") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			fn()
+		}()
+	}
+
+	entries := logs.All()
+	if entries[0].Level != zapcore.FatalLevel || entries[0].Message != "exit 1" {
+		t.Fatalf("unexpected fatal entry %+v", entries[0])
+	}
+	if entries[1].Level != zapcore.PanicLevel || entries[1].ContextMap()["key"] != "value" {
+		t.Fatalf("unexpected panic entry %+v", entries[1])
+	}
+}
+
+func TestSugaredWithKeepsLevelsAndBoundFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	levels := NewLevels(zapcore.InfoLevel)
+	root := NewLogger(zap.New(core), WithLevels(levels))
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "req-1"))
+	ctx = NewContext(ctx, root.Named("redis"))
+	bound := FromContext(ctx).Sugar().With("tenant", "acme")
+
+	bound.Debug("dropped")
+	levels.SetLevel("redis", zapcore.DebugLevel)
+	bound.DebugwCtx(ctx, "forced")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %v", entries)
+	}
+	if entries[0].LoggerName != "redis" {
+		t.Fatalf("logger name = %q", entries[0].LoggerName)
+	}
+	// the bound request_id is not repeated by the Ctx call
+	n := 0
+	for _, f := range entries[0].Context {
+		if f.Key == "request_id" {
+			n++
+		}
+	}
+	fields := entries[0].ContextMap()
+	if n != 1 || fields["tenant"] != "acme" {
+		t.Fatalf("fields = %v", entries[0].Context)
+	}
+}
+
+func TestSugaredRedaction(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s := NewLogger(zap.New(core), WithRedaction()).Sugar()
+
+	s.Infow("call", "Authorization", "Bearer abc")
+	s.With("password", "hunter2").Info("login")
+
+	if got := logs.All()[0].ContextMap()["Authorization"]; got != "[REDACTED]" {
+		t.Fatalf("sugared field not redacted: %v", got)
+	}
+	if got := logs.All()[1].ContextMap()["password"]; got != "[REDACTED]" {
+		t.Fatalf("sugared With field not redacted: %v", got)
+	}
+}
+
+type countingStringer struct{ calls *int }
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return "formatted"
+}
+
+func TestSugaredCtxSkipsDisabledLevels(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	var extracted, formatted int
+	s := NewLogger(zap.New(core), WithContextExtractors(func(ctx context.Context) []zap.Field {
+		extracted++
+		return nil
+	})).Sugar()
+
+	ctx := context.Background()
+	arg := countingStringer{calls: &formatted}
+	s.DebugCtx(ctx, arg)
+	s.DebugfCtx(ctx, "%s", arg)
+	s.DebugwCtx(ctx, "debug", "arg", arg)
+	if extracted != 0 || formatted != 0 || logs.Len() != 0 {
+		t.Fatalf("disabled level ran extractors %d times and formatting %d times", extracted, formatted)
+	}
+
+	s.InfofCtx(ctx, "%s", arg)
+	if extracted != 1 || formatted != 1 || logs.Len() != 1 {
+		t.Fatalf("extracted = %d, formatted = %d, entries = %d", extracted, formatted, logs.Len())
+	}
+}
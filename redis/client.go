@@ -4,8 +4,29 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-func NewClient(opts *redis.Options) *redis.Client {
+func NewClient(opts *redis.Options, options ...Option) *redis.Client {
+	o := defaultOptions()
+	o.apply(options...)
+
+	// go-redis logger must be replaced before the client is created
+	if o.installLogger != nil {
+		o.installLogger()
+	}
+
 	client := redis.NewClient(opts)
 	client.AddHook(traceInterceptor("redis", opts))
+
+	if o.detectorEnabled {
+		client.AddHook(detectorInterceptor(o))
+	}
+	if o.hotKeys != nil {
+		client.AddHook(hotKeyInterceptor(o.hotKeys))
+	}
+	if o.faultInjector != nil {
+		client.AddHook(faultInterceptor(o.faultInjector))
+	}
+	if o.namespace != "" || o.tenantNamespace != nil {
+		client.AddHook(namespaceInterceptor(o.namespace, o.tenantNamespace))
+	}
 	return client
 }
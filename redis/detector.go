@@ -0,0 +1,273 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/duolacloud/micro/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cast"
+	"go.uber.org/zap"
+)
+
+// Policy decides what happens to a dangerous command
+type Policy int
+
+const (
+	// PolicyWarn logs the command and lets it run
+	PolicyWarn Policy = iota
+	// PolicyBlock rejects the command with ErrCommandBlocked
+	PolicyBlock
+	// PolicyAllow lets the command run silently
+	PolicyAllow
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyWarn:
+		return "warn"
+	case PolicyBlock:
+		return "block"
+	case PolicyAllow:
+		return "allow"
+	}
+	return "unknown"
+}
+
+// ErrCommandBlocked is returned for dangerous commands under PolicyBlock
+var ErrCommandBlocked = errors.New("redis: command blocked by policy")
+
+// defaultPolicies flag commands that scan the keyspace or a whole value
+func defaultPolicies() map[string]Policy {
+	return map[string]Policy{
+		"keys":     PolicyWarn,
+		"flushall": PolicyWarn,
+		"flushdb":  PolicyWarn,
+		"smembers": PolicyWarn,
+		"hgetall":  PolicyWarn,
+	}
+}
+
+type detectorMetrics struct {
+	slow      *prometheus.CounterVec
+	big       *prometheus.CounterVec
+	dangerous *prometheus.CounterVec
+}
+
+func newDetectorMetrics(reg prometheus.Registerer) *detectorMetrics {
+	return &detectorMetrics{
+		slow: registerCollector(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redis_client_slow_commands_total",
+			Help: "Total number of redis commands slower than the threshold.",
+		}, []string{"command"})),
+		big: registerCollector(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redis_client_big_replies_total",
+			Help: "Total number of redis replies larger than the limit.",
+		}, []string{"command"})),
+		dangerous: registerCollector(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redis_client_dangerous_commands_total",
+			Help: "Total number of dangerous redis commands by policy.",
+		}, []string{"command", "policy"})),
+	}
+}
+
+// registerCollector registers c or returns the collector already
+// registered, so several clients can share the metrics
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+type startKey struct{}
+
+// detectorInterceptor logs slow commands, big replies and dangerous commands
+func detectorInterceptor(o *options) *interceptor {
+	logger := o.logger
+	if logger == nil {
+		logger = logging.NewLogger(zap.NewNop())
+	}
+	metrics := newDetectorMetrics(o.registerer)
+
+	check := func(ctx context.Context, cmd redis.Cmder) error {
+		policy, ok := o.policies[cmd.Name()]
+		if !ok || policy == PolicyAllow {
+			return nil
+		}
+		metrics.dangerous.WithLabelValues(cmd.Name(), policy.String()).Inc()
+		logger.WarnCtx(ctx, "redis dangerous command",
+			zap.String("command", cmd.FullName()),
+			zap.String("key", firstKey(cmd)),
+			zap.Stringer("policy", policy),
+		)
+		if policy == PolicyBlock {
+			cmd.SetErr(ErrCommandBlocked)
+			return ErrCommandBlocked
+		}
+		return nil
+	}
+
+	checkReply := func(ctx context.Context, cmd redis.Cmder) {
+		if o.bigReplyLimit <= 0 {
+			return
+		}
+		if size := replySize(cmd); size > o.bigReplyLimit {
+			metrics.big.WithLabelValues(cmd.Name()).Inc()
+			logger.WarnCtx(ctx, "redis big reply",
+				zap.String("command", cmd.FullName()),
+				zap.String("key", firstKey(cmd)),
+				zap.Int("size", size),
+			)
+		}
+	}
+
+	checkElapsed := func(ctx context.Context, name string) {
+		start, ok := ctx.Value(startKey{}).(time.Time)
+		if !ok || o.slowThreshold <= 0 {
+			return
+		}
+		if elapsed := time.Since(start); elapsed > o.slowThreshold {
+			metrics.slow.WithLabelValues(name).Inc()
+			logger.WarnCtx(ctx, "redis slow command",
+				zap.String("command", name),
+				zap.Duration("elapsed", elapsed),
+			)
+		}
+	}
+
+	return newInterceptor().
+		setBeforeProcess(func(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+			if err := check(ctx, cmd); err != nil {
+				return ctx, err
+			}
+			return context.WithValue(ctx, startKey{}, time.Now()), nil
+		}).
+		setAfterProcess(func(ctx context.Context, cmd redis.Cmder) error {
+			checkElapsed(ctx, cmd.FullName())
+			checkReply(ctx, cmd)
+			return nil
+		}).
+		setBeforeProcessPipeline(func(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+			for _, cmd := range cmds {
+				if err := check(ctx, cmd); err != nil {
+					return ctx, err
+				}
+			}
+			return context.WithValue(ctx, startKey{}, time.Now()), nil
+		}).
+		setAfterProcessPipeline(func(ctx context.Context, cmds []redis.Cmder) error {
+			checkElapsed(ctx, "pipeline "+getCmdsName(cmds))
+			for _, cmd := range cmds {
+				checkReply(ctx, cmd)
+			}
+			return nil
+		})
+}
+
+func firstKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+	positions, _ := keyPositions(args)
+	if len(positions) == 0 {
+		return ""
+	}
+	return cast.ToString(args[positions[0]])
+}
+
+// replySize approximates the reply size in bytes for the common reply types
+func replySize(cmd redis.Cmder) int {
+	switch c := cmd.(type) {
+	case *redis.Cmd:
+		return valueSize(c.Val())
+	case *redis.SliceCmd:
+		return valueSize(c.Val())
+	case *redis.StringCmd:
+		return len(c.Val())
+	case *redis.StringSliceCmd:
+		return stringsSize(c.Val())
+	case *redis.MapStringStringCmd:
+		size := 0
+		for k, v := range c.Val() {
+			size += len(k) + len(v)
+		}
+		return size
+	case *redis.KeyValueSliceCmd:
+		size := 0
+		for _, kv := range c.Val() {
+			size += len(kv.Key) + len(kv.Value)
+		}
+		return size
+	case *redis.KeyValuesCmd:
+		key, values := c.Val()
+		return len(key) + stringsSize(values)
+	case *redis.ZSliceCmd:
+		size := 0
+		for _, z := range c.Val() {
+			size += valueSize(z.Member) + 8
+		}
+		return size
+	case *redis.ScanCmd:
+		keys, _ := c.Val()
+		return stringsSize(keys)
+	case *redis.XMessageSliceCmd:
+		return messagesSize(c.Val())
+	case *redis.XStreamSliceCmd:
+		size := 0
+		for _, s := range c.Val() {
+			size += len(s.Stream) + messagesSize(s.Messages)
+		}
+		return size
+	}
+	return 0
+}
+
+func valueSize(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case []interface{}:
+		size := 0
+		for _, e := range v {
+			size += valueSize(e)
+		}
+		return size
+	case map[interface{}]interface{}:
+		size := 0
+		for k, e := range v {
+			size += valueSize(k) + valueSize(e)
+		}
+		return size
+	case nil:
+		return 0
+	}
+	return 8
+}
+
+func stringsSize(values []string) int {
+	size := 0
+	for _, v := range values {
+		size += len(v)
+	}
+	return size
+}
+
+func messagesSize(messages []redis.XMessage) int {
+	size := 0
+	for _, m := range messages {
+		size += len(m.ID)
+		for k, v := range m.Values {
+			size += len(k) + valueSize(v)
+		}
+	}
+	return size
+}
@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/duolacloud/micro/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// counterValue returns the value of the counter name with the given label values
+func counterValue(t *testing.T, reg *prometheus.Registry, name string, labels ...string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range family.GetMetric() {
+			for i, label := range m.GetLabel() {
+				if i >= len(labels) || label.GetValue() != labels[i] {
+					continue next
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func newDetectorClient(t *testing.T, opts ...Option) (*redis.Client, *miniredis.Miniredis, *observer.ObservedLogs, *prometheus.Registry) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg := prometheus.NewRegistry()
+	opts = append([]Option{WithLogger(logging.NewLogger(zap.New(core))), WithRegisterer(reg)}, opts...)
+	client := NewClient(&redis.Options{Addr: mr.Addr()}, opts...)
+	t.Cleanup(func() { client.Close() })
+	return client, mr, logs, reg
+}
+
+func TestDetectorSlowCommand(t *testing.T) {
+	ctx := context.Background()
+	client, _, logs, reg := newDetectorClient(t, WithSlowThreshold(time.Nanosecond))
+
+	client.Set(ctx, "k", "v", 0)
+	pipe := client.Pipeline()
+	pipe.Get(ctx, "k")
+	pipe.Exec(ctx)
+
+	slow := logs.FilterMessage("redis slow command").All()
+	if len(slow) != 2 {
+		t.Fatalf("slow logs = %v", slow)
+	}
+	if got := slow[1].ContextMap()["command"]; got != "pipeline get" {
+		t.Fatalf("pipeline command = %v", got)
+	}
+	if v := counterValue(t, reg, "redis_client_slow_commands_total", "set"); v != 1 {
+		t.Fatalf("slow counter = %v", v)
+	}
+
+	fast, _, fastLogs, _ := newDetectorClient(t, WithSlowThreshold(time.Hour))
+	fast.Set(ctx, "k", "v", 0)
+	if fastLogs.FilterMessage("redis slow command").Len() != 0 {
+		t.Fatal("fast command reported as slow")
+	}
+}
+
+func TestDetectorBigReply(t *testing.T) {
+	ctx := context.Background()
+	client, mr, logs, reg := newDetectorClient(t, WithBigReplyLimit(10))
+	mr.Set("big", strings.Repeat("x", 100))
+	mr.Set("small", "x")
+
+	client.Get(ctx, "small")
+	client.Get(ctx, "big")
+
+	big := logs.FilterMessage("redis big reply").All()
+	if len(big) != 1 {
+		t.Fatalf("big reply logs = %v", big)
+	}
+	fields := big[0].ContextMap()
+	if fields["key"] != "big" || fields["size"] != int64(100) {
+		t.Fatalf("fields = %v", fields)
+	}
+	if v := counterValue(t, reg, "redis_client_big_replies_total", "get"); v != 1 {
+		t.Fatalf("big counter = %v", v)
+	}
+}
+
+func TestDetectorDangerousCommands(t *testing.T) {
+	ctx := context.Background()
+	client, mr, logs, reg := newDetectorClient(t,
+		WithCommandPolicy(PolicyBlock, "FLUSHALL"),
+		WithCommandPolicy(PolicyAllow, "hgetall"),
+	)
+	mr.Set("k", "v")
+
+	// warned by default and still run
+	if err := client.Keys(ctx, "*").Err(); err != nil {
+		t.Fatal(err)
+	}
+	// blocked commands never reach redis
+	if err := client.FlushAll(ctx).Err(); err != ErrCommandBlocked {
+		t.Fatalf("flushall err = %v", err)
+	}
+	if !mr.Exists("k") {
+		t.Fatal("blocked flushall ran")
+	}
+	pipe := client.Pipeline()
+	pipe.Get(ctx, "k")
+	pipe.FlushAll(ctx)
+	if _, err := pipe.Exec(ctx); err != ErrCommandBlocked {
+		t.Fatalf("pipeline err = %v", err)
+	}
+	// allowed commands are silent
+	client.HGetAll(ctx, "h")
+
+	dangerous := logs.FilterMessage("redis dangerous command").All()
+	if len(dangerous) != 3 {
+		t.Fatalf("dangerous logs = %v", dangerous)
+	}
+	if got := dangerous[0].ContextMap()["policy"]; got != "warn" {
+		t.Fatalf("keys policy = %v", got)
+	}
+	if v := counterValue(t, reg, "redis_client_dangerous_commands_total", "keys", "warn"); v != 1 {
+		t.Fatalf("keys counter = %v", v)
+	}
+	if v := counterValue(t, reg, "redis_client_dangerous_commands_total", "flushall", "block"); v != 2 {
+		t.Fatalf("flushall counter = %v", v)
+	}
+	if v := counterValue(t, reg, "redis_client_dangerous_commands_total", "hgetall", "allow"); v != 0 {
+		t.Fatalf("hgetall counter = %v", v)
+	}
+}
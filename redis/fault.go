@@ -0,0 +1,297 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cast"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FaultKind is the kind of fault injected
+type FaultKind string
+
+const (
+	// FaultLatency delays the command and then runs it
+	FaultLatency FaultKind = "latency"
+	// FaultError fails the command with Message
+	FaultError FaultKind = "error"
+	// FaultTimeout waits Latency, 3s by default, and fails with a timeout error
+	FaultTimeout FaultKind = "timeout"
+	// FaultNil answers redis.Nil without running the command
+	FaultNil FaultKind = "nil"
+)
+
+// FaultRule injects a fault into commands matching Command and Key with
+// the given Probability. Command and Key are path.Match patterns, empty
+// matches everything. Latency is in nanoseconds when encoded as JSON.
+type FaultRule struct {
+	Command     string        `json:"command,omitempty"`
+	Key         string        `json:"key,omitempty"`
+	Kind        FaultKind     `json:"kind"`
+	Probability float64       `json:"probability"`
+	Latency     time.Duration `json:"latency,omitempty"`
+	Message     string        `json:"message,omitempty"`
+}
+
+func (r *FaultRule) match(cmd redis.Cmder) bool {
+	if r.Command != "" {
+		if ok, _ := path.Match(strings.ToLower(r.Command), cmd.Name()); !ok {
+			return false
+		}
+	}
+	if r.Key == "" {
+		return true
+	}
+	args := cmd.Args()
+	positions, _ := keyPositions(args)
+	for _, i := range positions {
+		if ok, _ := path.Match(r.Key, cast.ToString(args[i])); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultFaultTimeout matches the go-redis default read timeout
+const defaultFaultTimeout = 3 * time.Second
+
+type faultTimeoutError struct{}
+
+func (faultTimeoutError) Error() string   { return "redis: injected fault: i/o timeout" }
+func (faultTimeoutError) Timeout() bool   { return true }
+func (faultTimeoutError) Temporary() bool { return true }
+
+// FaultInjector makes redis misbehave on demand, it is disabled until
+// Enable is called or rules are PUT through ServeHTTP
+type FaultInjector struct {
+	enabled atomic.Bool
+	mu      sync.RWMutex
+	rules   []FaultRule
+}
+
+// NewFaultInjector returns a disabled FaultInjector with rules
+func NewFaultInjector(rules ...FaultRule) *FaultInjector {
+	return &FaultInjector{rules: rules}
+}
+
+// Enable turns injection on or off
+func (f *FaultInjector) Enable(enabled bool) {
+	f.enabled.Store(enabled)
+}
+
+// Enabled reports whether faults are injected
+func (f *FaultInjector) Enabled() bool {
+	return f.enabled.Load()
+}
+
+// SetRules replaces the rules
+func (f *FaultInjector) SetRules(rules ...FaultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = rules
+}
+
+// Rules returns a copy of the rules
+func (f *FaultInjector) Rules() []FaultRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]FaultRule(nil), f.rules...)
+}
+
+type faultState struct {
+	Enabled bool        `json:"enabled"`
+	Rules   []FaultRule `json:"rules"`
+}
+
+// ServeHTTP exposes the injector as an admin endpoint: GET returns the
+// state, PUT replaces it and DELETE disables injection and clears the rules
+func (f *FaultInjector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var state faultState
+		if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.SetRules(state.Rules...)
+		f.Enable(state.Enabled)
+	case http.MethodDelete:
+		f.Enable(false)
+		f.SetRules()
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&faultState{Enabled: f.Enabled(), Rules: f.Rules()})
+}
+
+// pick returns the first matching rule that fires
+func (f *FaultInjector) pick(cmd redis.Cmder) (FaultRule, bool) {
+	if !f.Enabled() {
+		return FaultRule{}, false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, rule := range f.rules {
+		if rule.match(cmd) && rand.Float64() < rule.Probability {
+			return rule, true
+		}
+	}
+	return FaultRule{}, false
+}
+
+// inject applies rule, a non nil error means the command must not run
+func (f *FaultInjector) inject(ctx context.Context, cmd redis.Cmder, rule FaultRule) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Bool("redis.fault_injected", true))
+	span.AddEvent("redis.fault", trace.WithAttributes(
+		attribute.String("fault.kind", string(rule.Kind)),
+		attribute.String("fault.command", cmd.FullName()),
+		attribute.String("fault.latency", rule.Latency.String()),
+	))
+
+	switch rule.Kind {
+	case FaultLatency:
+		return sleep(ctx, rule.Latency)
+	case FaultError:
+		msg := rule.Message
+		if msg == "" {
+			msg = "redis: injected fault"
+		}
+		return errors.New(msg)
+	case FaultTimeout:
+		latency := rule.Latency
+		if latency <= 0 {
+			latency = defaultFaultTimeout
+		}
+		if err := sleep(ctx, latency); err != nil {
+			return err
+		}
+		return faultTimeoutError{}
+	case FaultNil:
+		return redis.Nil
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// faultHook injects faults from f. Faulted commands never reach redis, in a
+// pipeline they are removed and the rest is sent, a faulted transaction is
+// not sent at all.
+type faultHook struct {
+	f *FaultInjector
+}
+
+func faultInterceptor(f *FaultInjector) *faultHook {
+	return &faultHook{f: f}
+}
+
+func (h *faultHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *faultHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.fault(ctx, cmd); err != nil {
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *faultHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if isTx(cmds) {
+			return h.faultTx(ctx, next, cmds)
+		}
+
+		var (
+			first error
+			rest  = cmds[:0:0]
+		)
+		for _, cmd := range cmds {
+			if err := h.fault(ctx, cmd); err != nil {
+				if first == nil {
+					first = err
+				}
+				continue
+			}
+			rest = append(rest, cmd)
+		}
+		if first == nil {
+			return next(ctx, cmds)
+		}
+		if len(rest) > 0 {
+			// a failure of the commands sent to redis is reported first
+			if err := next(ctx, rest); err != nil {
+				return err
+			}
+		}
+		return first
+	}
+}
+
+// faultTx fails the whole transaction when a fault fires on one of its
+// commands, a transaction is never sent partially
+func (h *faultHook) faultTx(ctx context.Context, next redis.ProcessPipelineHook, cmds []redis.Cmder) error {
+	var first error
+	for _, cmd := range cmds[1 : len(cmds)-1] {
+		if err := h.fault(ctx, cmd); err != nil {
+			first = err
+			break
+		}
+	}
+	if first == nil {
+		return next(ctx, cmds)
+	}
+	for _, cmd := range cmds {
+		if cmd.Err() == nil {
+			cmd.SetErr(first)
+		}
+	}
+	return first
+}
+
+// isTx reports whether cmds are a transaction wrapped in MULTI and EXEC
+func isTx(cmds []redis.Cmder) bool {
+	return len(cmds) >= 2 && cmds[0].Name() == "multi" && cmds[len(cmds)-1].Name() == "exec"
+}
+
+// fault injects a fault into cmd when a rule fires, a non nil error means
+// cmd must not run
+func (h *faultHook) fault(ctx context.Context, cmd redis.Cmder) error {
+	rule, ok := h.f.pick(cmd)
+	if !ok {
+		return nil
+	}
+	if err := h.f.inject(ctx, cmd, rule); err != nil {
+		cmd.SetErr(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newFaultClient(t *testing.T, f *FaultInjector) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := NewClient(&redis.Options{Addr: mr.Addr()}, WithFaultInjector(f))
+	t.Cleanup(func() { client.Close() })
+	return client, mr
+}
+
+func TestFaultRuleMatch(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		rule FaultRule
+		args []interface{}
+		want bool
+	}{
+		{FaultRule{}, []interface{}{"get", "k"}, true},
+		{FaultRule{Command: "GET"}, []interface{}{"get", "k"}, true},
+		{FaultRule{Command: "h*"}, []interface{}{"hget", "k", "f"}, true},
+		{FaultRule{Command: "set"}, []interface{}{"get", "k"}, false},
+		{FaultRule{Key: "user:*"}, []interface{}{"get", "user:1"}, true},
+		{FaultRule{Key: "user:*"}, []interface{}{"mget", "order:1", "user:2"}, true},
+		{FaultRule{Key: "user:*"}, []interface{}{"get", "order:1"}, false},
+		{FaultRule{Command: "get", Key: "user:*"}, []interface{}{"set", "user:1", "v"}, false},
+		{FaultRule{Key: "*"}, []interface{}{"ping"}, false},
+	}
+	for _, c := range cases {
+		if got := c.rule.match(redis.NewCmd(ctx, c.args...)); got != c.want {
+			t.Errorf("%+v match %v = %v, want %v", c.rule, c.args, got, c.want)
+		}
+	}
+}
+
+func TestFaultKinds(t *testing.T) {
+	ctx := context.Background()
+	f := NewFaultInjector()
+	f.Enable(true)
+	client, mr := newFaultClient(t, f)
+
+	f.SetRules(FaultRule{Command: "set", Kind: FaultLatency, Probability: 1, Latency: 20 * time.Millisecond})
+	start := time.Now()
+	if err := client.Set(ctx, "k", "v", 0).Err(); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("latency: err = %v after %v", err, time.Since(start))
+	}
+	if got, _ := mr.Get("k"); got != "v" {
+		t.Fatalf("latency fault must still run the command, k = %q", got)
+	}
+
+	f.SetRules(FaultRule{Command: "set", Kind: FaultError, Probability: 1, Message: "boom"})
+	if err := client.Set(ctx, "k", "error", 0).Err(); err == nil || err.Error() != "boom" {
+		t.Fatalf("error: err = %v", err)
+	}
+
+	f.SetRules(FaultRule{Command: "set", Kind: FaultTimeout, Probability: 1, Latency: time.Millisecond})
+	err := client.Set(ctx, "k", "timeout", 0).Err()
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("timeout: err = %v", err)
+	}
+
+	f.SetRules(FaultRule{Command: "set", Kind: FaultNil, Probability: 1})
+	if err := client.Set(ctx, "k", "nil", 0).Err(); err != redis.Nil {
+		t.Fatalf("nil: err = %v", err)
+	}
+
+	if got, _ := mr.Get("k"); got != "v" {
+		t.Fatalf("faulted commands must not run, k = %q", got)
+	}
+}
+
+func TestFaultProbability(t *testing.T) {
+	ctx := context.Background()
+	f := NewFaultInjector(FaultRule{Kind: FaultError, Probability: 0})
+	f.Enable(true)
+	client, _ := newFaultClient(t, f)
+
+	for i := 0; i < 100; i++ {
+		if err := client.Ping(ctx).Err(); err != nil {
+			t.Fatalf("probability 0 fired: %v", err)
+		}
+	}
+
+	f.SetRules(FaultRule{Kind: FaultError, Probability: 1})
+	f.Enable(false)
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Fatalf("disabled injector fired: %v", err)
+	}
+	f.Enable(true)
+	if err := client.Ping(ctx).Err(); err == nil {
+		t.Fatal("probability 1 did not fire")
+	}
+}
+
+func TestFaultPipeline(t *testing.T) {
+	ctx := context.Background()
+	f := NewFaultInjector(FaultRule{Key: "b", Kind: FaultError, Probability: 1})
+	f.Enable(true)
+	client, mr := newFaultClient(t, f)
+
+	pipe := client.Pipeline()
+	a := pipe.Set(ctx, "a", "1", 0)
+	b := pipe.Set(ctx, "b", "1", 0)
+	if _, err := pipe.Exec(ctx); err == nil {
+		t.Fatal("pipeline error not reported")
+	}
+	if a.Err() != nil || b.Err() == nil {
+		t.Fatalf("a = %v, b = %v", a.Err(), b.Err())
+	}
+	if mr.Exists("b") || !mr.Exists("a") {
+		t.Fatalf("keys = %v, only a must be written", mr.Keys())
+	}
+
+	// a failure of the commands that were sent is not hidden by the fault
+	mr.Close()
+	pipe = client.Pipeline()
+	pipe.Set(ctx, "a", "1", 0)
+	pipe.Set(ctx, "b", "1", 0)
+	if _, err := pipe.Exec(ctx); err == nil || strings.Contains(err.Error(), "injected") {
+		t.Fatalf("err = %v, want the connection error", err)
+	}
+}
+
+func TestFaultTxPipeline(t *testing.T) {
+	ctx := context.Background()
+	f := NewFaultInjector(FaultRule{Key: "b", Kind: FaultError, Probability: 1})
+	f.Enable(true)
+	client, mr := newFaultClient(t, f)
+
+	pipe := client.TxPipeline()
+	a := pipe.Set(ctx, "a", "1", 0)
+	b := pipe.Set(ctx, "b", "1", 0)
+	if _, err := pipe.Exec(ctx); err == nil {
+		t.Fatal("transaction error not reported")
+	}
+	if a.Err() == nil || b.Err() == nil {
+		t.Fatalf("a = %v, b = %v, the whole transaction must fail", a.Err(), b.Err())
+	}
+	if len(mr.Keys()) != 0 {
+		t.Fatalf("keys = %v, nothing must be written", mr.Keys())
+	}
+
+	// transactions without a faulted command still run
+	f.SetRules(FaultRule{Key: "other", Kind: FaultError, Probability: 1})
+	pipe = client.TxPipeline()
+	pipe.Set(ctx, "a", "1", 0)
+	if _, err := pipe.Exec(ctx); err != nil || !mr.Exists("a") {
+		t.Fatalf("err = %v, keys = %v", err, mr.Keys())
+	}
+}
+
+func TestFaultSpanAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "call")
+
+	f := NewFaultInjector(FaultRule{Command: "get", Kind: FaultNil, Probability: 1})
+	f.Enable(true)
+	next := func(ctx context.Context, cmd redis.Cmder) error {
+		t.Fatal("faulted command ran")
+		return nil
+	}
+	faultInterceptor(f).ProcessHook(next)(ctx, redis.NewStringCmd(ctx, "get", "k"))
+	span.End()
+
+	got := recorder.Ended()[0]
+	found := false
+	for _, attr := range got.Attributes() {
+		if attr.Key == "redis.fault_injected" && attr.Value.AsBool() {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("attributes = %v", got.Attributes())
+	}
+	events := got.Events()
+	if len(events) != 1 || events[0].Name != "redis.fault" {
+		t.Fatalf("events = %v", events)
+	}
+	for _, attr := range events[0].Attributes {
+		if attr.Key == "fault.kind" && attr.Value.AsString() != "nil" {
+			t.Fatalf("fault.kind = %v", attr.Value)
+		}
+	}
+}
+
+func TestFaultInjectorServeHTTP(t *testing.T) {
+	f := NewFaultInjector()
+	do := func(method, body string) (int, string) {
+		req := httptest.NewRequest(method, "/faults", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		f.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, body := do(http.MethodGet, ""); code != http.StatusOK || !strings.Contains(body, `"enabled":false`) {
+		t.Fatalf("GET = %d %s", code, body)
+	}
+
+	code, _ := do(http.MethodPut, `{"enabled":true,"rules":[{"command":"get","kind":"error","probability":0.5}]}`)
+	if code != http.StatusOK || !f.Enabled() || len(f.Rules()) != 1 || f.Rules()[0].Kind != FaultError {
+		t.Fatalf("PUT = %d, enabled %v, rules %+v", code, f.Enabled(), f.Rules())
+	}
+	if code, _ := do(http.MethodPut, `{`); code != http.StatusBadRequest {
+		t.Fatalf("bad PUT = %d", code)
+	}
+
+	if code, _ := do(http.MethodDelete, ""); code != http.StatusOK || f.Enabled() || len(f.Rules()) != 0 {
+		t.Fatalf("DELETE = %d, enabled %v, rules %+v", code, f.Enabled(), f.Rules())
+	}
+	if code, _ := do(http.MethodPost, ""); code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST = %d", code)
+	}
+}
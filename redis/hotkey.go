@@ -0,0 +1,251 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash/maphash"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cast"
+)
+
+// HotKey is a key and its estimated request count in a window
+type HotKey struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+}
+
+// HotKeyOption set hot key option
+type HotKeyOption func(*hotKeyOptions)
+
+type hotKeyOptions struct {
+	size       int
+	window     time.Duration
+	sampleRate float64
+	hash       func(key string) string
+	registerer prometheus.Registerer
+}
+
+func defaultHotKeyOptions() *hotKeyOptions {
+	return &hotKeyOptions{
+		size:       20,
+		window:     time.Minute,
+		sampleRate: 0.1,
+		hash:       HashKey,
+		registerer: prometheus.DefaultRegisterer,
+	}
+}
+
+// WithHotKeySize set how many top keys are reported
+func WithHotKeySize(size int) HotKeyOption {
+	return func(o *hotKeyOptions) {
+		o.size = size
+	}
+}
+
+// WithHotKeyWindow set the reporting window
+func WithHotKeyWindow(window time.Duration) HotKeyOption {
+	return func(o *hotKeyOptions) {
+		o.window = window
+	}
+}
+
+// WithHotKeySampleRate set the fraction of commands sampled, between 0 and 1
+func WithHotKeySampleRate(rate float64) HotKeyOption {
+	return func(o *hotKeyOptions) {
+		o.sampleRate = rate
+	}
+}
+
+// WithHotKeyHash set how keys are reported, default is HashKey,
+// nil reports raw keys
+func WithHotKeyHash(hash func(key string) string) HotKeyOption {
+	return func(o *hotKeyOptions) {
+		if hash == nil {
+			hash = func(key string) string { return key }
+		}
+		o.hash = hash
+	}
+}
+
+// WithHotKeyRegisterer set the prometheus registerer, default is prometheus.DefaultRegisterer
+func WithHotKeyRegisterer(registerer prometheus.Registerer) HotKeyOption {
+	return func(o *hotKeyOptions) {
+		o.registerer = registerer
+	}
+}
+
+// HashKey returns a short sha256 digest of key, operators can hash a
+// suspected key the same way to match it against the report
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+const (
+	sketchDepth = 4
+	sketchWidth = 2048
+)
+
+var hotKeyDesc = prometheus.NewDesc(
+	"redis_client_hot_key_requests",
+	"Estimated requests of the hottest redis keys in the last window.",
+	[]string{"key"}, nil,
+)
+
+// HotKeys samples keys into a count-min sketch and keeps the heaviest
+// keys of each window. The report of the last complete window is exported
+// as the redis_client_hot_key_requests gauge and served as JSON.
+// Only the first HotKeys registered on a registerer is exported.
+type HotKeys struct {
+	opts *hotKeyOptions
+
+	mu          sync.Mutex
+	seeds       [sketchDepth]maphash.Seed
+	sketch      [sketchDepth][sketchWidth]uint32
+	candidates  map[string]uint64
+	windowStart time.Time
+	report      []HotKey
+}
+
+// NewHotKeys returns a hot key tracker, pass it to NewClient with WithHotKeys
+func NewHotKeys(opts ...HotKeyOption) *HotKeys {
+	o := defaultHotKeyOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	h := &HotKeys{
+		opts:        o,
+		candidates:  make(map[string]uint64, o.size),
+		windowStart: time.Now(),
+	}
+	for i := range h.seeds {
+		h.seeds[i] = maphash.MakeSeed()
+	}
+	registerCollector(o.registerer, h)
+	return h
+}
+
+// Observe samples a key access
+func (h *HotKeys) Observe(key string) {
+	if h.opts.sampleRate < 1 && rand.Float64() >= h.opts.sampleRate {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rotate(time.Now())
+
+	est := uint32(0)
+	for i := range h.sketch {
+		cell := &h.sketch[i][maphash.String(h.seeds[i], key)%sketchWidth]
+		*cell++
+		if i == 0 || *cell < est {
+			est = *cell
+		}
+	}
+
+	if _, ok := h.candidates[key]; ok || len(h.candidates) < h.opts.size {
+		h.candidates[key] = uint64(est)
+		return
+	}
+	// replace the lightest candidate when key overtakes it
+	minKey, minCount := "", uint64(0)
+	for k, c := range h.candidates {
+		if minKey == "" || c < minCount {
+			minKey, minCount = k, c
+		}
+	}
+	if uint64(est) > minCount {
+		delete(h.candidates, minKey)
+		h.candidates[key] = uint64(est)
+	}
+}
+
+// Top returns the hottest keys of the last complete window
+func (h *HotKeys) Top() []HotKey {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rotate(time.Now())
+	return append([]HotKey(nil), h.report...)
+}
+
+// ServeHTTP serves the last report as JSON
+func (h *HotKeys) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"window": h.opts.window.String(),
+		"keys":   h.Top(),
+	})
+}
+
+// Describe implements prometheus.Collector
+func (h *HotKeys) Describe(ch chan<- *prometheus.Desc) {
+	ch <- hotKeyDesc
+}
+
+// Collect implements prometheus.Collector, windows are also rotated on
+// scrape so the report does not go stale once traffic stops
+func (h *HotKeys) Collect(ch chan<- prometheus.Metric) {
+	for _, hk := range h.Top() {
+		ch <- prometheus.MustNewConstMetric(hotKeyDesc, prometheus.GaugeValue, float64(hk.Count), hk.Key)
+	}
+}
+
+func (h *HotKeys) rotate(now time.Time) {
+	elapsed := now.Sub(h.windowStart)
+	if elapsed < h.opts.window {
+		return
+	}
+
+	// the candidates belong to the last complete window only when no
+	// window without traffic followed it
+	var report []HotKey
+	if elapsed < 2*h.opts.window {
+		report = make([]HotKey, 0, len(h.candidates))
+		for k, c := range h.candidates {
+			report = append(report, HotKey{
+				Key:   h.opts.hash(k),
+				Count: uint64(float64(c) / h.opts.sampleRate),
+			})
+		}
+		sort.Slice(report, func(i, j int) bool { return report[i].Count > report[j].Count })
+	}
+	h.report = report
+
+	h.sketch = [sketchDepth][sketchWidth]uint32{}
+	h.candidates = make(map[string]uint64, h.opts.size)
+	h.windowStart = h.windowStart.Add(elapsed - elapsed%h.opts.window)
+}
+
+// hotKeyInterceptor feeds the keys of every command to h
+func hotKeyInterceptor(h *HotKeys) *interceptor {
+	observe := func(cmd redis.Cmder) {
+		args := cmd.Args()
+		positions, _ := keyPositions(args)
+		for _, i := range positions {
+			h.Observe(cast.ToString(args[i]))
+		}
+	}
+
+	return newInterceptor().
+		setBeforeProcess(func(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+			observe(cmd)
+			return ctx, nil
+		}).
+		setBeforeProcessPipeline(func(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+			for _, cmd := range cmds {
+				observe(cmd)
+			}
+			return ctx, nil
+		})
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHotKeys(t *testing.T) {
+	const window = 200 * time.Millisecond
+	reg := prometheus.NewRegistry()
+	h := NewHotKeys(
+		WithHotKeySize(3),
+		WithHotKeySampleRate(1),
+		WithHotKeyWindow(window),
+		WithHotKeyHash(nil),
+		WithHotKeyRegisterer(reg),
+	)
+	for i := 0; i < 1000; i++ {
+		h.Observe("hot")
+		h.Observe(fmt.Sprintf("cold-%d", i))
+		if i%2 == 0 {
+			h.Observe("warm")
+		}
+	}
+	if top := h.Top(); len(top) != 0 {
+		t.Fatalf("top = %v before the window ends, want empty", top)
+	}
+
+	time.Sleep(window)
+	top := h.Top()
+	if len(top) != 3 {
+		t.Fatalf("len(top) = %d, want 3", len(top))
+	}
+	if top[0].Key != "hot" || top[0].Count < 1000 {
+		t.Errorf("top[0] = %+v, want hot", top[0])
+	}
+	if top[1].Key != "warm" || top[1].Count < 500 {
+		t.Errorf("top[1] = %+v, want warm", top[1])
+	}
+	if n := testutil.CollectAndCount(reg, "redis_client_hot_key_requests"); n != 3 {
+		t.Errorf("gauge series = %d, want 3", n)
+	}
+
+	// the report of a window without traffic is empty, also when only scraped
+	time.Sleep(2 * window)
+	if n := testutil.CollectAndCount(reg, "redis_client_hot_key_requests"); n != 0 {
+		t.Errorf("gauge series = %d after idle windows, want 0", n)
+	}
+}
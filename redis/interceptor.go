@@ -36,11 +36,10 @@ func (i *interceptor) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 		}
 
 		err = next(ctx, cmd)
-		if err != nil {
-			return err
+		if afterErr := i.afterProcess(ctx, cmd); err == nil {
+			err = afterErr
 		}
-
-		return i.afterProcess(ctx, cmd)
+		return err
 	}
 }
 
@@ -53,11 +52,10 @@ func (i *interceptor) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.
 		}
 
 		err = next(ctx, cmds)
-		if err != nil {
-			return err
+		if afterErr := i.afterProcessPipeline(ctx, cmds); err == nil {
+			err = afterErr
 		}
-
-		return i.afterProcessPipeline(ctx, cmds)
+		return err
 	}
 }
 
@@ -91,7 +89,7 @@ func traceInterceptor(compName string, opts *redis.Options) *interceptor {
 			return nil
 		}).
 		setBeforeProcessPipeline(func(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
-			ctx, span := tracer.Start(ctx, "pipeline",  trace.WithAttributes(attrs...))
+			ctx, span := tracer.Start(ctx, "pipeline", trace.WithAttributes(attrs...))
 			span.SetAttributes(
 				semconv.DBOperationKey.String(getCmdsName(cmds)),
 			)
@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// keySpec describes key positions of a command the same way as COMMAND INFO,
+// a negative last position is counted from the end of the args
+type keySpec struct {
+	first, last, step int
+}
+
+var keySpecs = map[string]keySpec{}
+
+func registerKeySpec(spec keySpec, names ...string) {
+	for _, name := range names {
+		keySpecs[name] = spec
+	}
+}
+
+func init() {
+	registerKeySpec(keySpec{1, 1, 1},
+		// strings
+		"get", "set", "setnx", "setex", "psetex", "getset", "getdel", "getex", "append", "strlen",
+		"incr", "decr", "incrby", "decrby", "incrbyfloat", "getrange", "setrange",
+		"setbit", "getbit", "bitcount", "bitpos", "bitfield", "bitfield_ro",
+		// generic
+		"expire", "pexpire", "expireat", "pexpireat", "expiretime", "pexpiretime", "ttl", "pttl",
+		"persist", "type", "dump", "restore", "sort", "sort_ro",
+		// lists
+		"lpush", "rpush", "lpushx", "rpushx", "lpop", "rpop", "llen", "lrange", "lindex",
+		"lset", "lrem", "ltrim", "linsert", "lpos",
+		// sets
+		"sadd", "srem", "smembers", "sismember", "smismember", "scard", "spop", "srandmember", "sscan",
+		// hashes
+		"hset", "hsetnx", "hget", "hmset", "hmget", "hdel", "hexists", "hlen", "hkeys", "hvals",
+		"hgetall", "hincrby", "hincrbyfloat", "hstrlen", "hscan", "hrandfield",
+		"hexpire", "hpexpire", "hexpireat", "hpexpireat", "httl", "hpttl", "hexpiretime", "hpexpiretime", "hpersist",
+		// sorted sets
+		"zadd", "zrem", "zscore", "zmscore", "zincrby", "zcard", "zcount", "zlexcount",
+		"zrange", "zrangebyscore", "zrangebylex", "zrevrange", "zrevrangebyscore", "zrevrangebylex",
+		"zrank", "zrevrank", "zremrangebyrank", "zremrangebyscore", "zremrangebylex",
+		"zpopmin", "zpopmax", "zrandmember", "zscan",
+		// hyperloglog, geo and streams
+		"pfadd", "geoadd", "geodist", "geohash", "geopos", "georadius", "georadius_ro",
+		"georadiusbymember", "georadiusbymember_ro", "geosearch",
+		"xadd", "xlen", "xrange", "xrevrange", "xdel", "xtrim", "xack", "xclaim", "xautoclaim", "xpending",
+	)
+	registerKeySpec(keySpec{1, -1, 1},
+		"del", "unlink", "exists", "touch", "watch", "mget", "pfcount", "pfmerge",
+		"sdiff", "sinter", "sunion", "sdiffstore", "sinterstore", "sunionstore",
+	)
+	registerKeySpec(keySpec{1, -1, 2}, "mset", "msetnx")
+	registerKeySpec(keySpec{1, 2, 1},
+		"rename", "renamenx", "copy", "rpoplpush", "brpoplpush", "lmove", "blmove", "smove",
+		"zrangestore", "geosearchstore", "lcs",
+	)
+	registerKeySpec(keySpec{1, -2, 1}, "blpop", "brpop", "bzpopmin", "bzpopmax")
+	registerKeySpec(keySpec{2, -1, 1}, "bitop")
+	registerKeySpec(keySpec{2, 2, 1}, "object", "xinfo", "xgroup")
+}
+
+// numKeysCommands have a numkeys argument at the given position followed by the keys
+var numKeysCommands = map[string]int{
+	"eval": 2, "evalsha": 2, "eval_ro": 2, "evalsha_ro": 2, "fcall": 2, "fcall_ro": 2,
+	"zunion": 1, "zinter": 1, "zdiff": 1, "zintercard": 1, "sintercard": 1,
+	"lmpop": 1, "zmpop": 1, "blmpop": 2, "bzmpop": 2,
+	"zunionstore": 2, "zinterstore": 2, "zdiffstore": 2,
+}
+
+// keylessCommands do not address keys and are passed through
+var keylessCommands = map[string]bool{
+	"ping": true, "echo": true, "info": true, "time": true, "auth": true, "hello": true,
+	"select": true, "client": true, "config": true, "command": true, "quit": true,
+	"multi": true, "exec": true, "discard": true, "unwatch": true, "wait": true,
+	"script": true, "function": true, "publish": true, "spublish": true,
+	"readonly": true, "readwrite": true, "cluster": true, "role": true,
+	"slowlog": true, "latency": true, "acl": true, "lastsave": true,
+}
+
+// keyPositions returns the indexes of key args, ok is false when the command
+// is unknown or addresses the whole keyspace
+func keyPositions(args []interface{}) (positions []int, ok bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	name := strings.ToLower(cast.ToString(args[0]))
+
+	if spec, found := keySpecs[name]; found {
+		last := spec.last
+		if last < 0 {
+			last = len(args) + last
+		}
+		for i := spec.first; i <= last && i < len(args); i += spec.step {
+			positions = append(positions, i)
+		}
+		return positions, true
+	}
+
+	if pos, found := numKeysCommands[name]; found {
+		if strings.HasSuffix(name, "store") {
+			positions = append(positions, 1)
+		}
+		if pos >= len(args) {
+			return positions, true
+		}
+		n := cast.ToInt(args[pos])
+		for i := pos + 1; i <= pos+n && i < len(args); i++ {
+			positions = append(positions, i)
+		}
+		return positions, true
+	}
+
+	switch name {
+	case "memory":
+		if len(args) > 2 && strings.EqualFold(cast.ToString(args[1]), "usage") {
+			return []int{2}, true
+		}
+		return nil, true
+	case "xread", "xreadgroup":
+		// keys are the first half of the args following STREAMS
+		for i := 1; i < len(args); i++ {
+			if strings.EqualFold(cast.ToString(args[i]), "streams") {
+				n := (len(args) - i - 1) / 2
+				for j := i + 1; j <= i+n; j++ {
+					positions = append(positions, j)
+				}
+				break
+			}
+		}
+		return positions, true
+	}
+
+	return nil, keylessCommands[name]
+}
@@ -0,0 +1,160 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cast"
+)
+
+var (
+	// ErrCrossNamespace is returned for commands that would reach keys outside the namespace
+	ErrCrossNamespace = errors.New("redis: command crosses namespace")
+	// ErrNoTenant is returned when the tenant namespace is missing from context
+	ErrNoTenant = errors.New("redis: no tenant in context")
+)
+
+// namespaceInterceptor prefix keys with namespace and the tenant from context,
+// keys in replies (KEYS, SCAN, blocking and multi pops, XREAD) are returned
+// without the prefix.
+// Scripts are only namespaced through their KEYS arguments.
+func namespaceInterceptor(namespace string, tenant func(ctx context.Context) (string, bool)) *interceptor {
+	prefixOf := func(ctx context.Context) (string, error) {
+		var prefix string
+		if namespace != "" {
+			prefix = namespace + ":"
+		}
+		if tenant != nil {
+			t, ok := tenant(ctx)
+			if !ok || t == "" {
+				return "", ErrNoTenant
+			}
+			prefix += t + ":"
+		}
+		return prefix, nil
+	}
+
+	before := func(ctx context.Context, cmd redis.Cmder) error {
+		prefix, err := prefixOf(ctx)
+		if err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		if err := prefixCmd(cmd, prefix); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return nil
+	}
+
+	after := func(ctx context.Context, cmd redis.Cmder) {
+		prefix, err := prefixOf(ctx)
+		if err != nil {
+			return
+		}
+		stripCmd(cmd, prefix)
+	}
+
+	return newInterceptor().
+		setBeforeProcess(func(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+			return ctx, before(ctx, cmd)
+		}).
+		setAfterProcess(func(ctx context.Context, cmd redis.Cmder) error {
+			after(ctx, cmd)
+			return nil
+		}).
+		setBeforeProcessPipeline(func(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+			for _, cmd := range cmds {
+				if err := before(ctx, cmd); err != nil {
+					return ctx, err
+				}
+			}
+			return ctx, nil
+		}).
+		setAfterProcessPipeline(func(ctx context.Context, cmds []redis.Cmder) error {
+			for _, cmd := range cmds {
+				after(ctx, cmd)
+			}
+			return nil
+		})
+}
+
+func prefixCmd(cmd redis.Cmder, prefix string) error {
+	args := cmd.Args()
+	switch cmd.Name() {
+	case "keys":
+		if len(args) > 1 {
+			args[1] = prefix + cast.ToString(args[1])
+		}
+		return nil
+	case "scan":
+		// replies are filtered as well since SCAN without MATCH cannot be rewritten
+		for i := 2; i < len(args)-1; i++ {
+			if strings.EqualFold(cast.ToString(args[i]), "match") {
+				args[i+1] = prefix + cast.ToString(args[i+1])
+			}
+		}
+		return nil
+	}
+
+	positions, ok := keyPositions(args)
+	if !ok {
+		return ErrCrossNamespace
+	}
+	for _, i := range positions {
+		args[i] = prefix + cast.ToString(args[i])
+	}
+	return nil
+}
+
+func stripCmd(cmd redis.Cmder, prefix string) {
+	switch c := cmd.(type) {
+	case *redis.ScanCmd:
+		// SSCAN, HSCAN and ZSCAN reply with members of a single key
+		if c.Name() == "scan" {
+			keys, cursor := c.Val()
+			c.SetVal(stripKeys(keys, prefix), cursor)
+		}
+	case *redis.StringSliceCmd:
+		switch c.Name() {
+		case "keys":
+			c.SetVal(stripKeys(c.Val(), prefix))
+		case "blpop", "brpop":
+			// reply is [key, value]
+			if val := c.Val(); len(val) > 0 {
+				val[0] = strings.TrimPrefix(val[0], prefix)
+			}
+		}
+	case *redis.XStreamSliceCmd:
+		// XREAD and XREADGROUP
+		streams := c.Val()
+		for i := range streams {
+			streams[i].Stream = strings.TrimPrefix(streams[i].Stream, prefix)
+		}
+	case *redis.ZWithKeyCmd:
+		// BZPOPMIN and BZPOPMAX
+		if val := c.Val(); val != nil {
+			val.Key = strings.TrimPrefix(val.Key, prefix)
+		}
+	case *redis.KeyValuesCmd:
+		// LMPOP and BLMPOP
+		key, val := c.Val()
+		c.SetVal(strings.TrimPrefix(key, prefix), val)
+	case *redis.ZSliceWithKeyCmd:
+		// ZMPOP and BZMPOP
+		key, val := c.Val()
+		c.SetVal(strings.TrimPrefix(key, prefix), val)
+	}
+}
+
+func stripKeys(keys []string, prefix string) []string {
+	stripped := keys[:0]
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			stripped = append(stripped, key[len(prefix):])
+		}
+	}
+	return stripped
+}
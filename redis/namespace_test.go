@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPrefixCmd(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		args []interface{}
+		want []interface{}
+	}{
+		{[]interface{}{"get", "k"}, []interface{}{"get", "ns:k"}},
+		{[]interface{}{"mget", "a", "b"}, []interface{}{"mget", "ns:a", "ns:b"}},
+		{[]interface{}{"mset", "a", 1, "b", 2}, []interface{}{"mset", "ns:a", 1, "ns:b", 2}},
+		{[]interface{}{"del", "a", []byte("b")}, []interface{}{"del", "ns:a", "ns:b"}},
+		{[]interface{}{"eval", "return 1", 2, "a", "b", "arg"}, []interface{}{"eval", "return 1", 2, "ns:a", "ns:b", "arg"}},
+		{[]interface{}{"zunionstore", "d", 2, "a", "b", "weights", 1, 2}, []interface{}{"zunionstore", "ns:d", 2, "ns:a", "ns:b", "weights", 1, 2}},
+		{[]interface{}{"blpop", "a", "b", 0}, []interface{}{"blpop", "ns:a", "ns:b", 0}},
+		{[]interface{}{"xread", "count", 1, "streams", "a", "b", "0", "0"}, []interface{}{"xread", "count", 1, "streams", "ns:a", "ns:b", "0", "0"}},
+		{[]interface{}{"keys", "user:*"}, []interface{}{"keys", "ns:user:*"}},
+		{[]interface{}{"ping"}, []interface{}{"ping"}},
+	}
+	for _, c := range cases {
+		cmd := redis.NewCmd(ctx, c.args...)
+		if err := prefixCmd(cmd, "ns:"); err != nil {
+			t.Fatalf("%v: %v", c.args, err)
+		}
+		if !reflect.DeepEqual(cmd.Args(), c.want) {
+			t.Errorf("args = %v, want %v", cmd.Args(), c.want)
+		}
+	}
+}
+
+func TestPrefixCmdCrossNamespace(t *testing.T) {
+	for _, name := range []string{"flushall", "flushdb", "randomkey", "swapdb", "unknown"} {
+		cmd := redis.NewCmd(context.Background(), name)
+		if err := prefixCmd(cmd, "ns:"); !errors.Is(err, ErrCrossNamespace) {
+			t.Errorf("%s: err = %v, want %v", name, err, ErrCrossNamespace)
+		}
+	}
+}
+
+func TestStripCmd(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		cmd  func() redis.Cmder
+		val  func(cmd redis.Cmder) interface{}
+		want interface{}
+	}{
+		{
+			name: "scan",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewScanCmd(ctx, nil, "scan", 0)
+				cmd.SetVal([]string{"ns:a", "other:b", "ns:c"}, 7)
+				return cmd
+			},
+			val: func(cmd redis.Cmder) interface{} {
+				keys, cursor := cmd.(*redis.ScanCmd).Val()
+				return []interface{}{keys, cursor}
+			},
+			want: []interface{}{[]string{"a", "c"}, uint64(7)},
+		},
+		{
+			name: "hscan",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewScanCmd(ctx, nil, "hscan", "ns:h", 0)
+				cmd.SetVal([]string{"field", "ns:value"}, 0)
+				return cmd
+			},
+			val: func(cmd redis.Cmder) interface{} {
+				keys, _ := cmd.(*redis.ScanCmd).Val()
+				return keys
+			},
+			want: []string{"field", "ns:value"},
+		},
+		{
+			name: "keys",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewStringSliceCmd(ctx, "keys", "ns:*")
+				cmd.SetVal([]string{"ns:a", "ns:b"})
+				return cmd
+			},
+			val:  func(cmd redis.Cmder) interface{} { return cmd.(*redis.StringSliceCmd).Val() },
+			want: []string{"a", "b"},
+		},
+		{
+			name: "blpop",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewStringSliceCmd(ctx, "blpop", "ns:l", 0)
+				cmd.SetVal([]string{"ns:l", "ns:value"})
+				return cmd
+			},
+			val:  func(cmd redis.Cmder) interface{} { return cmd.(*redis.StringSliceCmd).Val() },
+			want: []string{"l", "ns:value"},
+		},
+		{
+			name: "xread",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewXStreamSliceCmd(ctx, "xread", "streams", "ns:s", "0")
+				cmd.SetVal([]redis.XStream{{Stream: "ns:s"}})
+				return cmd
+			},
+			val:  func(cmd redis.Cmder) interface{} { return cmd.(*redis.XStreamSliceCmd).Val()[0].Stream },
+			want: "s",
+		},
+		{
+			name: "bzpopmin",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewZWithKeyCmd(ctx, "bzpopmin", "ns:z", 0)
+				cmd.SetVal(&redis.ZWithKey{Key: "ns:z", Z: redis.Z{Member: "ns:m"}})
+				return cmd
+			},
+			val:  func(cmd redis.Cmder) interface{} { return *cmd.(*redis.ZWithKeyCmd).Val() },
+			want: redis.ZWithKey{Key: "z", Z: redis.Z{Member: "ns:m"}},
+		},
+		{
+			name: "lmpop",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewKeyValuesCmd(ctx, "lmpop", 1, "ns:l", "left")
+				cmd.SetVal("ns:l", []string{"ns:value"})
+				return cmd
+			},
+			val: func(cmd redis.Cmder) interface{} {
+				key, val := cmd.(*redis.KeyValuesCmd).Val()
+				return []interface{}{key, val}
+			},
+			want: []interface{}{"l", []string{"ns:value"}},
+		},
+		{
+			name: "zmpop",
+			cmd: func() redis.Cmder {
+				cmd := redis.NewZSliceWithKeyCmd(ctx, "zmpop", 1, "ns:z", "min")
+				cmd.SetVal("ns:z", []redis.Z{{Member: "ns:m"}})
+				return cmd
+			},
+			val: func(cmd redis.Cmder) interface{} {
+				key, val := cmd.(*redis.ZSliceWithKeyCmd).Val()
+				return []interface{}{key, val}
+			},
+			want: []interface{}{"z", []redis.Z{{Member: "ns:m"}}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := c.cmd()
+			stripCmd(cmd, "ns:")
+			if got := c.val(cmd); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("reply = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceStripsAfterErrors(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := NewClient(&redis.Options{Addr: mr.Addr()}, WithNamespace("ns"))
+	defer client.Close()
+
+	if err := client.Set(ctx, "a", "1", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the missing key fails the pipeline with redis.Nil, replies of the
+	// other commands must still be stripped
+	pipe := client.Pipeline()
+	pipe.Get(ctx, "missing")
+	keys := pipe.Keys(ctx, "*")
+	if _, err := pipe.Exec(ctx); err != redis.Nil {
+		t.Fatalf("pipeline err = %v", err)
+	}
+	if !reflect.DeepEqual(keys.Val(), []string{"a"}) {
+		t.Fatalf("keys = %v", keys.Val())
+	}
+	if !mr.Exists("ns:a") {
+		t.Fatalf("keys in redis = %v", mr.Keys())
+	}
+}
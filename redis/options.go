@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/duolacloud/micro/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Option set client option
+type Option func(*options)
+
+type options struct {
+	namespace       string
+	tenantNamespace func(ctx context.Context) (string, bool)
+	logger          logging.Logger
+	registerer      prometheus.Registerer
+	slowThreshold   time.Duration
+	bigReplyLimit   int
+	policies        map[string]Policy
+	detectorEnabled bool
+	hotKeys         *HotKeys
+	faultInjector   *FaultInjector
+	installLogger   func()
+}
+
+func defaultOptions() *options {
+	return &options{
+		registerer: prometheus.DefaultRegisterer,
+		policies:   defaultPolicies(),
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithNamespace prefix every key with namespace + ":"
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithTenantNamespace prefix every key with the tenant taken from ctx,
+// commands issued without a tenant are rejected
+func WithTenantNamespace(fn func(ctx context.Context) (string, bool)) Option {
+	return func(o *options) {
+		o.tenantNamespace = fn
+	}
+}
+
+// WithInternalLogger route go-redis internal logging through logger.
+// go-redis logs globally, so this affects every client in the process and
+// only the first client created with this option installs its logger
+func WithInternalLogger(logger logging.Logger, opts ...logging.AdapterOption) Option {
+	return func(o *options) {
+		o.installLogger = func() {
+			logging.InstallRedisLoggerOnce(logger, opts...)
+		}
+	}
+}
+
+// WithLogger set logger used to report slow commands, big replies and dangerous commands
+func WithLogger(logger logging.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+		o.detectorEnabled = true
+	}
+}
+
+// WithRegisterer set the prometheus registerer, default is prometheus.DefaultRegisterer
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(o *options) {
+		o.registerer = registerer
+	}
+}
+
+// WithSlowThreshold report commands slower than threshold
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+		o.detectorEnabled = true
+	}
+}
+
+// WithBigReplyLimit report replies larger than limit bytes
+func WithBigReplyLimit(limit int) Option {
+	return func(o *options) {
+		o.bigReplyLimit = limit
+		o.detectorEnabled = true
+	}
+}
+
+// WithCommandPolicy set policy for commands, KEYS, FLUSHALL, FLUSHDB,
+// SMEMBERS and HGETALL are warned by default
+func WithCommandPolicy(policy Policy, commands ...string) Option {
+	return func(o *options) {
+		for _, command := range commands {
+			o.policies[strings.ToLower(command)] = policy
+		}
+		o.detectorEnabled = true
+	}
+}
+
+// WithHotKeys sample the keys of every command into h
+func WithHotKeys(h *HotKeys) Option {
+	return func(o *options) {
+		o.hotKeys = h
+	}
+}
+
+// WithFaultInjector inject faults from f into commands
+func WithFaultInjector(f *FaultInjector) Option {
+	return func(o *options) {
+		o.faultInjector = f
+	}
+}